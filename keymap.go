@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// Keys is the set of keys bound to one action, named as bubbletea reports
+// them, e.g. "j", "down", "enter", " " or "ctrl+d"
+type Keys []string
+
+// UnmarshalJSON accepts either a single key or a list of keys
+func (k *Keys) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*k = Keys{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("keys must be a string or a list of strings, got %s", data)
+	}
+	*k = list
+	return nil
+}
+
+// Matches reports whether key is bound to the action
+func (k Keys) Matches(key string) bool {
+	return slices.Contains(k, key)
+}
+
+// keyLabels spells out the keys whose bubbletea names read poorly in the footer
+var keyLabels = map[string]string{
+	"up":        "↑",
+	"down":      "↓",
+	"left":      "←",
+	"right":     "→",
+	" ":         "Space",
+	"enter":     "Enter",
+	"esc":       "Esc",
+	"tab":       "Tab",
+	"shift+tab": "Shift+Tab",
+	"home":      "Home",
+	"end":       "End",
+}
+
+// Label names the first key bound to the action, as the footer shows it, or
+// returns "" when no key is bound
+func (k Keys) Label() string {
+	if len(k) == 0 {
+		return ""
+	}
+	if label, ok := keyLabels[k[0]]; ok {
+		return label
+	}
+	return k[0]
+}
+
+// KeyMap binds keys to the actions of both viewers. Actions shared by the
+// viewers, like moving the cursor, use the same binding in each.
+type KeyMap struct {
+	Quit         Keys `json:"quit"`
+	MoveUp       Keys `json:"move_up"`
+	MoveDown     Keys `json:"move_down"`
+	MoveToTop    Keys `json:"move_to_top"`
+	MoveToBottom Keys `json:"move_to_bottom"`
+	MoveLeft     Keys `json:"move_left"` // Moves between columns, or scrolls a tree sideways
+	MoveRight    Keys `json:"move_right"`
+	Clear        Keys `json:"clear"` // Clears a search or filter, or closes the row detail or schema view
+	NextTab      Keys `json:"next_tab"`
+	PrevTab      Keys `json:"prev_tab"`
+
+	// JSON viewer
+	Toggle        Keys `json:"toggle"`
+	ExpandLevel   Keys `json:"expand_level"`
+	ExpandSubtree Keys `json:"expand_subtree"`
+	ExpandAll     Keys `json:"expand_all"`
+	CollapseAll   Keys `json:"collapse_all"`
+	ToggleTypes   Keys `json:"toggle_types"`
+	Search        Keys `json:"search"`
+	NextMatch     Keys `json:"next_match"`
+	PrevMatch     Keys `json:"prev_match"`
+	CopyPath      Keys `json:"copy_path"`
+	CopyJSON      Keys `json:"copy_json"`
+	Schema        Keys `json:"schema"`
+	RawView       Keys `json:"raw_view"`
+	GoToPath      Keys `json:"go_to_path"`
+
+	// CSV viewer
+	CellDetail     Keys `json:"cell_detail"`
+	ColumnStats    Keys `json:"column_stats"`
+	DistinctValues Keys `json:"distinct_values"`
+	ToggleColumn   Keys `json:"toggle_column"`
+	InvertColumns  Keys `json:"invert_columns"`
+	ShowAllColumns Keys `json:"show_all_columns"`
+	Sort           Keys `json:"sort"`
+	RowNumbers     Keys `json:"row_numbers"`
+	Ruler          Keys `json:"ruler"`
+	IsolateColumn  Keys `json:"isolate_column"`
+	WidenColumns   Keys `json:"widen_columns"`
+	NarrowColumns  Keys `json:"narrow_columns"`
+	FitColumns     Keys `json:"fit_columns"`
+	FreezeColumns  Keys `json:"freeze_columns"`
+	Filter         Keys `json:"filter"`
+	Duplicates     Keys `json:"duplicates"`
+	Unique         Keys `json:"unique"`
+	GoToColumn     Keys `json:"go_to_column"`
+	WriteView      Keys `json:"write_view"`
+	RowDetail      Keys `json:"row_detail"`
+	Transpose      Keys `json:"transpose"`
+}
+
+// DefaultKeyMap returns the standard bindings
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Quit:         Keys{"q"},
+		MoveUp:       Keys{"up", "k"},
+		MoveDown:     Keys{"down", "j"},
+		MoveToTop:    Keys{"home", "g"},
+		MoveToBottom: Keys{"end", "G"},
+		MoveLeft:     Keys{"left", "h"},
+		MoveRight:    Keys{"right", "l"},
+		Clear:        Keys{"esc"},
+		NextTab:      Keys{"tab"},
+		PrevTab:      Keys{"shift+tab"},
+
+		Toggle:        Keys{"enter", " "},
+		ExpandLevel:   Keys{"x"},
+		ExpandSubtree: Keys{"X"},
+		ExpandAll:     Keys{"E"},
+		CollapseAll:   Keys{"C"},
+		ToggleTypes:   Keys{"t"},
+		Search:        Keys{"/"},
+		NextMatch:     Keys{"n"},
+		PrevMatch:     Keys{"N"},
+		CopyPath:      Keys{"y"},
+		CopyJSON:      Keys{"Y"},
+		Schema:        Keys{"S"},
+		RawView:       Keys{"r"},
+		GoToPath:      Keys{":"},
+
+		CellDetail:     Keys{"enter"},
+		ColumnStats:    Keys{"="},
+		DistinctValues: Keys{"d"},
+		ToggleColumn:   Keys{"v"},
+		InvertColumns:  Keys{"i"},
+		ShowAllColumns: Keys{"a"},
+		Sort:           Keys{"s"},
+		RowNumbers:     Keys{"#"},
+		Ruler:          Keys{"R"},
+		IsolateColumn:  Keys{"o"},
+		WidenColumns:   Keys{"+"},
+		NarrowColumns:  Keys{"-"},
+		FitColumns:     Keys{"f"},
+		FreezeColumns:  Keys{"F"},
+		Filter:         Keys{"/"},
+		Duplicates:     Keys{"D"},
+		Unique:         Keys{"U"},
+		GoToColumn:     Keys{":"},
+		WriteView:      Keys{"w"},
+		RowDetail:      Keys{"r"},
+		Transpose:      Keys{"T"},
+	}
+}
+
+// LoadKeyMap reads a JSON object of action names to keys, e.g.
+// {"toggle_column": "x", "move_down": ["down", "ctrl+n"]}. Actions it leaves
+// out keep their default bindings.
+func LoadKeyMap(r io.Reader) (KeyMap, error) {
+	keys := DefaultKeyMap()
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&keys); err != nil {
+		return KeyMap{}, fmt.Errorf("failed to parse keymap: %w", err)
+	}
+	return keys, nil
+}
+
+// loadKeyMapFile loads the keymap file at path
+func loadKeyMapFile(path string) (KeyMap, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return KeyMap{}, err
+	}
+	defer file.Close()
+	return LoadKeyMap(file)
+}
@@ -1,15 +1,32 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
-
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+	"tablux/pkg/loader"
+	"tablux/pkg/model"
 	"tablux/pkg/parser"
+	"tablux/pkg/tablux"
 	"tablux/pkg/ui"
+	"tablux/pkg/writer"
 )
 
 // UI constants
@@ -21,10 +38,37 @@ const (
 	TypeJSON  = "json"
 	TypeJSONL = "jsonl"
 	TypeCSV   = "csv"
+	TypeTSV   = "tsv"
+	TypeYAML  = "yaml"
+	TypeTOML  = "toml"
+	TypeEmpty = "empty" // Input with nothing but whitespace
+
+	// Shown instead of a viewer for empty input
+	NoDataMessage = "No data: the input is empty."
+
+	// Inputs larger than this are parsed into lazily built JSON trees
+	LazyJSONThreshold = 50 << 20
 
 	// Viewport padding
 	HeaderFooterSpace = 4 // Space needed for header and footer
 	CSVBorderSpace    = 6 // Extra space needed for CSV borders and padding
+	ViewChromeLines   = 3 // Title line plus the blank lines around the content
+	ContentTopLine    = 2 // Screen line the content starts on, below the title and a blank line
+	TabBarLines       = 1 // Lines the tab bar adds above the title when several files are open
+
+	// Column between the two panes of a diff, drawn with a box-drawing line
+	// or, with --ascii, a pipe
+	DiffSeparator      = " │ "
+	ASCIIDiffSeparator = " | "
+
+	// How often the loading screen redraws how much has been read
+	LoadProgressInterval = 100 * time.Millisecond
+
+	// Lines moved per mouse wheel notch
+	MouseWheelLines = 3
+
+	// Cells the JSON viewer scrolls sideways per key press
+	HorizontalScrollStep = 8
 
 	// Default sizes for non-interactive mode
 	DefaultHeight = 30
@@ -32,6 +76,9 @@ const (
 
 	// Input methods
 	InputStdin = "<stdin>"
+
+	// Output target meaning stdout
+	OutputStdout = "-"
 )
 
 // Colors
@@ -54,144 +101,865 @@ var (
 			Foreground(TextColor).
 			Italic(true).
 			PaddingLeft(1)
+
+	tabStyle = lipgloss.NewStyle().
+			Foreground(TextColor).
+			PaddingLeft(1).
+			PaddingRight(1)
+
+	activeTabStyle = tabStyle.
+			Bold(true).
+			Background(PrimaryColor)
 )
 
-// Model represents the application state
-type Model struct {
-	title      string
+// promptKind identifies what the footer text prompt is collecting
+type promptKind int
+
+const (
+	promptNone promptKind = iota
+	promptCSVFilter
+	promptJSONSearch
+	promptCSVColumn
+	promptCSVExport
+	promptJSONPath
+)
+
+// applyTheme makes theme the active theme for the application and viewer styles
+func applyTheme(theme ui.Theme) {
+	ui.SetTheme(theme)
+	PrimaryColor = lipgloss.Color(theme.Title)
+	TextColor = lipgloss.Color(theme.Text)
+	ErrorColor = lipgloss.Color(theme.Error)
+	titleStyle = titleStyle.Foreground(TextColor).Background(PrimaryColor)
+	infoStyle = infoStyle.Foreground(lipgloss.Color(theme.Footer))
+	tabStyle = tabStyle.Foreground(lipgloss.Color(theme.Footer))
+	activeTabStyle = activeTabStyle.Foreground(TextColor).Background(PrimaryColor)
+}
+
+// resolveTheme returns the built-in theme with the given name, or loads the
+// theme file at that path. "auto" picks the theme matching the terminal.
+func resolveTheme(nameOrPath string) (ui.Theme, error) {
+	if strings.ToLower(nameOrPath) == ui.AutoThemeName {
+		return ui.DetectTheme(), nil
+	}
+	if theme, ok := ui.BuiltinTheme(nameOrPath); ok {
+		return theme, nil
+	}
+
+	file, err := os.Open(nameOrPath)
+	if err != nil {
+		return ui.Theme{}, fmt.Errorf("unknown theme %q: use %s, %s, or a theme file path", nameOrPath, ui.AutoThemeName, strings.Join(ui.ThemeNames(), ", "))
+	}
+	defer file.Close()
+	return ui.LoadTheme(file)
+}
+
+// disableColors strips colors from the application and viewer styles
+func disableColors() {
+	PrimaryColor, TextColor, ErrorColor = "", "", ""
+	titleStyle = titleStyle.UnsetForeground().UnsetBackground()
+	infoStyle = infoStyle.UnsetForeground()
+	tabStyle = tabStyle.UnsetForeground()
+	activeTabStyle = activeTabStyle.UnsetForeground().UnsetBackground().Reverse(true)
+	ui.DisableColors()
+}
+
+// fileTab is the state of one open input: its viewers and how loading it went
+type fileTab struct {
 	filePath   string
-	width      int
-	height     int
 	jsonViewer *ui.JSONViewer
+	rowDetail  *ui.JSONViewer // A CSV row shown as a JSON object over the table until Esc
+	schema     *ui.JSONViewer // The document's inferred structure, shown over it until S or Esc
+	raw        *ui.RawViewer  // The tree on screen as JSON text, shown over it until r or Esc
 	csvViewer  *ui.CSVViewer
 	viewerType string
 	isLoading  bool
 	errorMsg   string
+	stats      LoadStats     // How large the input was and how long it took to parse
+	progress   *LoadProgress // How much of the input has been read while it loads
+	watcher    *loader.FileWatcher
+}
+
+// Model represents the application state. The active tab is embedded, so
+// its viewers are the model's viewers until another tab is selected.
+type Model struct {
+	*fileTab
+	tabs        []*fileTab
+	active      int // Index of the embedded tab in tabs
+	title       string
+	width       int
+	height      int
+	prompt      promptKind
+	promptInput string
+	promptRegex bool   // The CSV filter prompt takes a regular expression
+	flash       string // One-off message shown in the footer until the next key
+	keys        KeyMap
+	diffing     bool            // The two tabs are shown side by side as a diff
+	diff        *model.TreeDiff // Set once both sides of the diff have loaded
+}
+
+// newModel creates a model with a tab loading each source, the first one active
+func newModel(sources []string, keys KeyMap) Model {
+	m := Model{title: AppName, keys: keys}
+	for _, source := range sources {
+		m.tabs = append(m.tabs, &fileTab{filePath: source, isLoading: true, progress: &LoadProgress{}})
+	}
+	m.fileTab = m.tabs[0]
+	return m
 }
 
 // Init initializes the application
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(
-		tea.EnterAltScreen,
-		loadSourceCmd(m.filePath),
-	)
+	cmds := []tea.Cmd{tea.EnterAltScreen}
+	for i, tab := range m.tabs {
+		cmds = append(cmds, loadSourceCmd(i, tab.filePath, tab.progress, false), progressTickCmd(i), waitForChangeCmd(i, tab.watcher))
+	}
+	return tea.Batch(cmds...)
 }
 
 // FileLoadedMsg is sent when a file is loaded
 type FileLoadedMsg struct {
+	tab        int // Index of the tab the file was loaded for
 	viewerType string
 	jsonViewer *ui.JSONViewer
 	csvViewer  *ui.CSVViewer
+	stats      LoadStats
 	error      error
+	reload     bool // Loaded again after a change to a watched file
+}
+
+// LoadProgressMsg is sent while a tab loads, to redraw how much is read
+type LoadProgressMsg struct {
+	tab int
+}
+
+// FileChangedMsg is sent when a watched file has changed
+type FileChangedMsg struct {
+	tab   int
+	error error
+}
+
+// LoadOptions controls how input is detected, parsed and first displayed
+type LoadOptions struct {
+	Format         string // Forced format, or empty to auto-detect
+	LazyJSON       bool   // Build JSON child nodes only when first expanded
+	MaxColumnWidth int    // CSV column width cap, or 0 for the default
+	Query          string // Path expression narrowing tree formats, e.g. .users[2]
+	WrapNavigation bool   // Moving past either end of a viewer continues from the other
+	Lenient        bool   // Skip malformed JSONL lines instead of failing
+	FitColumns     bool   // Size CSV columns to fill the terminal width
+	ExpandDepth    int    // Levels of a tree expanded when it opens, or 0 to keep them as parsed
+	Encoding       string // Character set to transcode the input from, or empty to detect it
+	Comment        rune   // Character starting CSV comment lines, or 0 to keep every line
+	TrimSpace      bool   // Strip whitespace around CSV fields
+
+	Header       parser.HeaderMode  // Whether the first CSV row names the columns
+	NumberFormat model.NumberFormat // How numbers are written in both viewers
+}
+
+// loadOptionsFromFlags collects the load options set on the command line
+func loadOptionsFromFlags() LoadOptions {
+	opts := LoadOptions{Comment: parser.DefaultComment}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "format":
+			opts.Format = f.Value.String()
+		case "lazy":
+			opts.LazyJSON = f.Value.String() == "true"
+		case "max-col-width":
+			opts.MaxColumnWidth = f.Value.(flag.Getter).Get().(int)
+		case "query":
+			opts.Query = f.Value.String()
+		case "wrap":
+			opts.WrapNavigation = f.Value.String() == "true"
+		case "lenient":
+			opts.Lenient = f.Value.String() == "true"
+		case "fit":
+			opts.FitColumns = f.Value.String() == "true"
+		case "expand-depth":
+			opts.ExpandDepth = f.Value.(flag.Getter).Get().(int)
+		case "encoding":
+			opts.Encoding = f.Value.String()
+		case "header":
+			opts.Header, _ = parser.ParseHeaderMode(f.Value.String())
+		case "trim":
+			opts.TrimSpace = f.Value.String() == "true"
+		case "comment":
+			opts.Comment = 0
+			for _, r := range f.Value.String() {
+				opts.Comment = r
+			}
+		case "thousands":
+			opts.NumberFormat.Thousands = f.Value.String() == "true"
+		case "decimals":
+			opts.NumberFormat.Decimals = f.Value.(flag.Getter).Get().(int)
+			opts.NumberFormat.Fixed = opts.NumberFormat.Decimals >= 0
+		case "scientific":
+			opts.NumberFormat.Scientific = f.Value.String() == "true"
+		}
+	})
+	return opts
+}
+
+// flagPassed reports whether the flag called name was given on the command line
+func flagPassed(name string) bool {
+	passed := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			passed = true
+		}
+	})
+	return passed
+}
+
+// validComment reports whether s can start CSV comment lines: empty, for no
+// comments, or one character that can't be part of the CSV syntax itself
+func validComment(s string) bool {
+	if s == "" {
+		return true
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return size == len(s) && r != utf8.RuneError && r != '"' && r != '\r' && r != '\n'
 }
 
 // parseFile parses data and returns appropriate viewer based on file type
 // If a specific format is provided, it will use that instead of auto-detection
-func parseFile(data []byte, forcedFormat string) (string, *ui.JSONViewer, *ui.CSVViewer, error) {
-	fileType := forcedFormat
+func parseFile(data []byte, opts LoadOptions) (string, *ui.JSONViewer, *ui.CSVViewer, error) {
+	if isBlank(data) {
+		return TypeEmpty, nil, nil, nil
+	}
 
-	// Auto-detect format if not forced
-	if fileType == "" {
-		fileType = parser.DetectFileType(data)
+	// Defer node construction for huge inputs
+	lazy := opts.LazyJSON || len(data) > LazyJSONThreshold
+	doc, err := tablux.Parse(data, opts.Format, tablux.Options{
+		Lazy:      lazy,
+		Lenient:   opts.Lenient,
+		Comment:   opts.Comment,
+		TrimSpace: opts.TrimSpace,
+		Header:    opts.Header,
+	})
+	if err != nil {
+		return "", nil, nil, err
 	}
 
-	switch fileType {
-	case TypeJSON, TypeJSONL:
-		// Parse JSON data
-		jsonParser := parser.NewJSONParser()
-		root, err := jsonParser.Parse(data)
-		if err != nil {
-			return "", nil, nil, err
-		}
+	if doc.Table != nil {
+		return doc.Format, nil, newCSVViewer(doc.Table, opts), nil
+	}
 
-		// Create JSON viewer
-		viewer := ui.NewJSONViewer(root)
-		return fileType, viewer, nil, nil
+	// Every tree format reuses the JSON tree viewer
+	viewer, err := newJSONViewer(doc.Tree, lazy, opts)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	viewer.Skipped = doc.Skipped
+	viewer.DuplicateKeys = doc.DuplicateKeys
+	return doc.Format, viewer, nil, nil
+}
 
-	case TypeCSV:
-		// Parse CSV data
-		csvParser := parser.NewCSVParser()
-		csvData, err := csvParser.Parse(data)
-		if err != nil {
-			return "", nil, nil, err
-		}
+// isBlank reports whether data holds nothing but whitespace
+func isBlank(data []byte) bool {
+	return len(bytes.TrimSpace(data)) == 0
+}
 
-		// Create CSV viewer
-		viewer := ui.NewCSVViewer(csvData)
-		return fileType, nil, viewer, nil
+// skipBlankSamples discards whole lines of whitespace from the front of r
+// for as long as its buffered sample holds nothing else, so detection sees
+// the content after a long blank stretch. It reports whether the input is
+// whitespace throughout.
+func skipBlankSamples(r *bufio.Reader) (bool, error) {
+	for {
+		sample, err := r.Peek(parser.DetectSampleSize)
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		if !isBlank(sample) {
+			return false, nil
+		}
+		if err == io.EOF {
+			return true, nil
+		}
 
-	default:
-		return "", nil, nil, fmt.Errorf("unsupported file type")
+		// Keep the blank start of a line the content may begin on
+		n := bytes.LastIndexByte(sample, '\n') + 1
+		if n == 0 {
+			n = len(sample)
+		}
+		r.Discard(n)
 	}
 }
 
-// loadSourceCmd loads data from a file or stdin and returns the appropriate viewer
-func loadSourceCmd(source string) tea.Cmd {
-	return func() tea.Msg {
-		// Get the format flag value
-		formatFlag := ""
-		flag.Visit(func(f *flag.Flag) {
-			if f.Name == "format" {
-				formatFlag = f.Value.String()
-			}
-		})
-
-		// Load data from source
-		data, err := readDataFromSource(source)
+// newJSONViewer creates a tree viewer, first narrowing the tree to the query
+// if one is set. lazy tells whether the tree was parsed lazily.
+func newJSONViewer(root *model.JSONNode, lazy bool, opts LoadOptions) (*ui.JSONViewer, error) {
+	if opts.Query != "" {
+		var err error
+		root, err = (&parser.JSONParser{Lazy: lazy}).Query(root, opts.Query)
 		if err != nil {
-			return FileLoadedMsg{error: err}
+			return nil, err
 		}
+	}
+	if opts.ExpandDepth > 0 {
+		root.ExpandToDepth(opts.ExpandDepth)
+	}
+	viewer := ui.NewJSONViewer(root)
+	viewer.WrapNavigation = opts.WrapNavigation
+	viewer.NumberFormat = opts.NumberFormat
+	return viewer, nil
+}
+
+// newCSVViewer creates a CSV viewer configured by the load options
+func newCSVViewer(data *parser.CSVData, opts LoadOptions) *ui.CSVViewer {
+	viewer := ui.NewCSVViewer(data)
+	if opts.MaxColumnWidth > 0 {
+		viewer.SetColumnMaxWidth(opts.MaxColumnWidth)
+	}
+	viewer.WrapNavigation = opts.WrapNavigation
+	viewer.SetFitColumns(opts.FitColumns)
+	if !opts.NumberFormat.IsZero() {
+		viewer.SetNumberFormat(opts.NumberFormat)
+	}
+	return viewer
+}
 
-		// Parse data with optional format
-		fileType, jsonViewer, csvViewer, err := parseFile(data, formatFlag)
+// loadSourceCmd loads data from a file or stdin for a tab and returns the appropriate viewer
+func loadSourceCmd(tab int, source string, progress *LoadProgress, reload bool) tea.Cmd {
+	if progress == nil {
+		progress = &LoadProgress{}
+	}
+	return func() tea.Msg {
+		// Load and parse data with options from the command line
+		start := time.Now()
+		fileType, jsonViewer, csvViewer, err := loadSource(source, loadOptionsFromFlags(), progress)
+		stats := LoadStats{Bytes: progress.Bytes(), Duration: time.Since(start)}
 		if err != nil {
-			return FileLoadedMsg{error: err}
+			return FileLoadedMsg{tab: tab, error: err, reload: reload}
 		}
 
 		return FileLoadedMsg{
+			tab:        tab,
 			viewerType: fileType,
 			jsonViewer: jsonViewer,
 			csvViewer:  csvViewer,
+			stats:      stats,
+			reload:     reload,
+		}
+	}
+}
+
+// progressTickCmd redraws a loading tab's progress after a short wait
+func progressTickCmd(tab int) tea.Cmd {
+	return tea.Tick(LoadProgressInterval, func(time.Time) tea.Msg {
+		return LoadProgressMsg{tab: tab}
+	})
+}
+
+// waitForChangeCmd waits for the next change to a tab's watched file, if any
+func waitForChangeCmd(tab int, watcher *loader.FileWatcher) tea.Cmd {
+	if watcher == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		changed, err := watcher.Wait()
+		if !changed {
+			return nil
 		}
+		return FileChangedMsg{tab: tab, error: err}
+	}
+}
+
+// switchTab makes the tab delta places after the active one active, wrapping
+// around at either end
+func (m *Model) switchTab(delta int) {
+	if len(m.tabs) < 2 {
+		return
 	}
+	m.selectTab((m.active + delta%len(m.tabs) + len(m.tabs)) % len(m.tabs))
+}
+
+// selectTab makes the tab at index i active
+func (m *Model) selectTab(i int) {
+	m.active = i
+	m.fileTab = m.tabs[i]
+}
+
+// applyDiff compares the two documents once both have loaded, and marks
+// each pane with its side of the differences
+func (m *Model) applyDiff() {
+	m.diff = nil
+	for _, tab := range m.tabs {
+		if tab.isLoading || tab.errorMsg != "" {
+			return
+		}
+	}
+	for _, tab := range m.tabs {
+		if tab.jsonViewer == nil {
+			tab.errorMsg = fmt.Sprintf("Error: --diff compares JSON, JSONL, YAML or TOML documents, but %s is %s", tab.filePath, tab.viewerType)
+			return
+		}
+	}
+
+	left, right := m.tabs[0].jsonViewer, m.tabs[1].jsonViewer
+	m.diff = model.DiffTrees(left.Root(), right.Root())
+	left.Diff, right.Diff = m.diff.Left, m.diff.Right
+	m.syncDiff()
+}
+
+// syncDiff makes the pane without focus expand, collapse and scroll along
+// with the focused one
+func (m *Model) syncDiff() {
+	if m.diff == nil {
+		return
+	}
+	other := m.tabs[1-m.active].jsonViewer
+	other.MatchExpanded(m.jsonViewer)
+	other.Follow(m.jsonViewer)
+}
+
+// paneWidth returns the width of each pane of a diff
+func (m Model) paneWidth() int {
+	return max((m.width-ansi.StringWidth(DiffSeparator))/2, 1)
 }
 
 // handleJSONKeyMsg processes key presses for JSON viewer
 func (m *Model) handleJSONKeyMsg(key string) {
-	if m.jsonViewer == nil {
+	if m.raw != nil {
+		m.handleRawKeyMsg(key)
 		return
 	}
 
-	switch key {
-	case "up":
-		m.jsonViewer.MoveUp()
-	case "down":
-		m.jsonViewer.MoveDown()
-	case "enter", " ":
-		m.jsonViewer.ToggleNode()
+	viewer := m.treeViewer()
+	if viewer == nil {
+		return
+	}
+
+	switch {
+	case m.keys.MoveUp.Matches(key):
+		viewer.MoveUp()
+	case m.keys.MoveDown.Matches(key):
+		viewer.MoveDown()
+	case m.keys.MoveToTop.Matches(key):
+		viewer.MoveToTop()
+	case m.keys.MoveToBottom.Matches(key):
+		viewer.MoveToBottom()
+	case m.keys.MoveLeft.Matches(key):
+		viewer.ScrollLeft(HorizontalScrollStep)
+	case m.keys.MoveRight.Matches(key):
+		viewer.ScrollRight(HorizontalScrollStep)
+	case m.keys.Toggle.Matches(key):
+		viewer.ToggleNode()
+	case m.keys.ExpandLevel.Matches(key):
+		viewer.ExpandNode(viewer.CurrentNode(), false)
+	case m.keys.ExpandSubtree.Matches(key):
+		viewer.ExpandNode(viewer.CurrentNode(), true)
+	case m.keys.ExpandAll.Matches(key):
+		viewer.ExpandAll()
+	case m.keys.CollapseAll.Matches(key):
+		viewer.CollapseAll()
+	case m.keys.ToggleTypes.Matches(key):
+		viewer.ToggleTypes()
+	case m.keys.Search.Matches(key):
+		m.openPrompt(promptJSONSearch)
+	case m.keys.NextMatch.Matches(key):
+		viewer.NextMatch()
+	case m.keys.PrevMatch.Matches(key):
+		viewer.PrevMatch()
+	case m.keys.GoToPath.Matches(key):
+		m.openPrompt(promptJSONPath)
+	case m.keys.Schema.Matches(key):
+		m.toggleSchema()
+	case m.keys.RawView.Matches(key):
+		m.toggleRaw()
+	case m.keys.Clear.Matches(key):
+		// Esc clears a search first, then closes the row detail or schema view
+		if viewer.SearchQuery() == "" && m.rowDetail != nil {
+			m.rowDetail = nil
+			return
+		}
+		if viewer.SearchQuery() == "" && m.schema != nil {
+			m.schema = nil
+			return
+		}
+		viewer.ClearSearch()
+	case m.keys.CopyPath.Matches(key):
+		m.copyCurrentPath()
+	case m.keys.CopyJSON.Matches(key):
+		m.copyCurrentSubtree()
+	}
+}
+
+// handleRawKeyMsg scrolls the raw JSON text, or goes back to the tree
+func (m *Model) handleRawKeyMsg(key string) {
+	switch {
+	case m.keys.MoveUp.Matches(key):
+		m.raw.ScrollUp(1)
+	case m.keys.MoveDown.Matches(key):
+		m.raw.ScrollDown(1)
+	case m.keys.MoveToTop.Matches(key):
+		m.raw.ScrollToTop()
+	case m.keys.MoveToBottom.Matches(key):
+		m.raw.ScrollToBottom()
+	case m.keys.MoveLeft.Matches(key):
+		m.raw.ScrollLeft(HorizontalScrollStep)
+	case m.keys.MoveRight.Matches(key):
+		m.raw.ScrollRight(HorizontalScrollStep)
+	case m.keys.RawView.Matches(key), m.keys.Clear.Matches(key):
+		m.toggleRaw()
 	}
 }
 
+// toggleRaw shows the tree on screen as pretty-printed JSON text, or goes
+// back to the tree
+func (m *Model) toggleRaw() {
+	if m.raw != nil {
+		m.raw = nil
+		return
+	}
+	// The panes of a diff only follow each other as trees
+	if m.treeViewer() == nil || m.diffing {
+		return
+	}
+	m.raw = ui.NewRawViewer(m.treeViewer().Root())
+}
+
+// treeViewer returns the tree viewer receiving input: the row detail view
+// while it is open over a table, the schema view while it is open over a
+// document, otherwise the document's JSON viewer
+func (m *Model) treeViewer() *ui.JSONViewer {
+	if m.rowDetail != nil {
+		return m.rowDetail
+	}
+	if m.schema != nil {
+		return m.schema
+	}
+	return m.jsonViewer
+}
+
+// activeViewerType returns the kind of viewer on screen, which is a JSON
+// tree while the row detail view is open
+func (m Model) activeViewerType() string {
+	if m.rowDetail != nil {
+		return TypeJSON
+	}
+	return m.viewerType
+}
+
+// toggleSchema shows the inferred structure of the document in place of its
+// values, or goes back to the values
+func (m *Model) toggleSchema() {
+	if m.schema != nil {
+		m.schema = nil
+		return
+	}
+	// Row details are too small to have much structure, and the panes of a
+	// diff only follow each other's values
+	if m.jsonViewer == nil || m.rowDetail != nil || m.diffing {
+		return
+	}
+	m.schema = ui.NewJSONViewer(model.InferSchema(m.jsonViewer.Root()))
+	m.schema.Schema = true
+	m.schema.WrapNavigation = m.jsonViewer.WrapNavigation
+}
+
+// openRowDetail shows the CSV row under the cursor as a JSON object
+func (m *Model) openRowDetail() {
+	if row := m.csvViewer.CurrentRowNode(); row != nil {
+		m.rowDetail = ui.NewJSONViewer(row)
+		m.rowDetail.WrapNavigation = m.csvViewer.WrapNavigation
+		m.rowDetail.NumberFormat = m.csvViewer.NumberFormat()
+	}
+}
+
+// copyCurrentPath copies the path of the node under the cursor to the clipboard
+func (m *Model) copyCurrentPath() {
+	node := m.treeViewer().CurrentNode()
+	if node == nil {
+		return
+	}
+
+	path := node.DisplayPath()
+	if err := clipboard.WriteAll(path); err != nil {
+		m.flash = fmt.Sprintf("Copy failed: %v", err)
+		return
+	}
+	m.flash = fmt.Sprintf("Copied %s", path)
+}
+
+// copyCurrentSubtree copies the node under the cursor, with its descendants, to the clipboard as JSON
+func (m *Model) copyCurrentSubtree() {
+	node := m.treeViewer().CurrentNode()
+	if node == nil {
+		return
+	}
+
+	data, err := node.ToJSON()
+	if err == nil {
+		err = clipboard.WriteAll(string(data))
+	}
+	if err != nil {
+		m.flash = fmt.Sprintf("Copy failed: %v", err)
+		return
+	}
+	m.flash = fmt.Sprintf("Copied %s as JSON", node.DisplayPath())
+}
+
 // handleCSVKeyMsg processes key presses for CSV viewer
 func (m *Model) handleCSVKeyMsg(key string) {
 	if m.csvViewer == nil {
 		return
 	}
 
-	switch key {
-	case "up":
+	switch {
+	case m.keys.MoveUp.Matches(key):
 		m.csvViewer.MoveUp()
-	case "down":
+	case m.keys.MoveDown.Matches(key):
 		m.csvViewer.MoveDown()
-	case "left":
+	case m.keys.MoveToTop.Matches(key):
+		m.csvViewer.MoveToTop()
+	case m.keys.MoveToBottom.Matches(key):
+		m.csvViewer.MoveToBottom()
+	case m.keys.MoveLeft.Matches(key):
 		m.csvViewer.MoveLeft()
-	case "right":
+	case m.keys.MoveRight.Matches(key):
 		m.csvViewer.MoveRight()
-	case "enter", " ":
+	case m.keys.CellDetail.Matches(key):
+		m.csvViewer.ToggleCellDetail()
+	case m.keys.ColumnStats.Matches(key):
+		m.csvViewer.ToggleColumnStats()
+	case m.keys.DistinctValues.Matches(key):
+		m.csvViewer.ToggleDistinctValues()
+	case m.keys.ToggleColumn.Matches(key):
 		m.csvViewer.ToggleColumnVisibility()
-	case "s":
-		m.csvViewer.SortByCurrentColumn()
+	case m.keys.InvertColumns.Matches(key):
+		m.csvViewer.InvertColumnVisibility()
+	case m.keys.ShowAllColumns.Matches(key):
+		m.csvViewer.ShowAllColumns()
+	case m.keys.Sort.Matches(key):
+		if m.csvViewer.Transposed() {
+			m.flash = "Sorting is off while the table is transposed"
+		} else {
+			m.csvViewer.SortByCurrentColumn()
+		}
+	case m.keys.RowNumbers.Matches(key):
+		m.csvViewer.ToggleRowNumbers()
+	case m.keys.Ruler.Matches(key):
+		m.csvViewer.ToggleRuler()
+	case m.keys.IsolateColumn.Matches(key):
+		m.csvViewer.IsolateColumn()
+	case m.keys.WidenColumns.Matches(key):
+		m.csvViewer.WidenColumns()
+	case m.keys.NarrowColumns.Matches(key):
+		m.csvViewer.NarrowColumns()
+	case m.keys.FitColumns.Matches(key):
+		m.csvViewer.ToggleFitColumns()
+	case m.keys.FreezeColumns.Matches(key):
+		m.csvViewer.ToggleFreezeColumns()
+	case m.keys.Filter.Matches(key):
+		m.openPrompt(promptCSVFilter)
+	case m.keys.GoToColumn.Matches(key):
+		m.openPrompt(promptCSVColumn)
+	case m.keys.WriteView.Matches(key):
+		m.openPrompt(promptCSVExport)
+	case m.keys.RowDetail.Matches(key):
+		m.openRowDetail()
+	case m.keys.Transpose.Matches(key):
+		m.csvViewer.ToggleTranspose()
+	case m.keys.Duplicates.Matches(key):
+		m.csvViewer.CycleDuplicateMode(ui.DuplicatesOnly)
+	case m.keys.Unique.Matches(key):
+		m.csvViewer.CycleDuplicateMode(ui.UniqueOnly)
+	case m.keys.Clear.Matches(key):
+		m.csvViewer.ClearFilter()
+		m.csvViewer.SetDuplicateMode(ui.DuplicatesOff, -1)
+	}
+}
+
+// handleMouseMsg selects what was clicked and scrolls the viewport, not the cursor, on wheel movement
+func (m *Model) handleMouseMsg(msg tea.MouseMsg) {
+	if msg.Action != tea.MouseActionPress {
+		return
+	}
+
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		m.scrollViewer(-MouseWheelLines)
+	case tea.MouseButtonWheelDown:
+		m.scrollViewer(MouseWheelLines)
+	case tea.MouseButtonLeft:
+		// Viewers take coordinates relative to where their content starts
+		x, y := msg.X, msg.Y-m.contentTopLine()
+		if m.diffing {
+			// A click in a pane also gives it focus
+			rightStart := m.paneWidth() + ansi.StringWidth(DiffSeparator)
+			switch {
+			case x >= rightStart:
+				m.selectTab(1)
+				x -= rightStart
+			case x < m.paneWidth():
+				m.selectTab(0)
+			default:
+				return
+			}
+		}
+		switch m.activeViewerType() {
+		case TypeJSON, TypeJSONL, TypeYAML, TypeTOML:
+			// The raw text has nothing to select
+			if m.raw == nil && m.treeViewer() != nil {
+				m.treeViewer().Click(x, y)
+			}
+		case TypeCSV, TypeTSV:
+			if m.csvViewer != nil {
+				m.csvViewer.Click(x, y)
+			}
+		}
+	}
+}
+
+// scrollViewer moves the active viewer's viewport by lines, up when negative
+func (m *Model) scrollViewer(lines int) {
+	switch m.activeViewerType() {
+	case TypeJSON, TypeJSONL, TypeYAML, TypeTOML:
+		if m.raw != nil {
+			if lines < 0 {
+				m.raw.ScrollUp(-lines)
+			} else {
+				m.raw.ScrollDown(lines)
+			}
+			return
+		}
+		if m.treeViewer() == nil {
+			return
+		}
+		if lines < 0 {
+			m.treeViewer().ScrollUp(-lines)
+		} else {
+			m.treeViewer().ScrollDown(lines)
+		}
+	case TypeCSV, TypeTSV:
+		if m.csvViewer == nil {
+			return
+		}
+		if lines < 0 {
+			m.csvViewer.ScrollUp(-lines)
+		} else {
+			m.csvViewer.ScrollDown(lines)
+		}
+	}
+}
+
+// openPrompt starts collecting text input in the footer
+func (m *Model) openPrompt(kind promptKind) {
+	m.prompt = kind
+	m.promptInput = ""
+}
+
+// handlePromptKey processes key presses while a text prompt is active
+func (m *Model) handlePromptKey(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.submitPrompt()
+		return
+	case tea.KeyEsc:
+		m.cancelPrompt()
+		return
+	case tea.KeyBackspace:
+		if runes := []rune(m.promptInput); len(runes) > 0 {
+			m.promptInput = string(runes[:len(runes)-1])
+		}
+	case tea.KeyCtrlR:
+		// Switch the filter between plain text and regular expressions
+		if m.prompt != promptCSVFilter {
+			return
+		}
+		m.promptRegex = !m.promptRegex
+	case tea.KeySpace:
+		m.promptInput += " "
+	case tea.KeyRunes:
+		m.promptInput += string(msg.Runes)
+	default:
+		return
+	}
+
+	m.onPromptChange()
+}
+
+// onPromptChange applies the prompt input as it is typed
+func (m *Model) onPromptChange() {
+	switch m.prompt {
+	case promptCSVFilter:
+		if m.csvViewer == nil {
+			return
+		}
+		if !m.promptRegex {
+			m.csvViewer.FilterRows(m.promptInput)
+			return
+		}
+		// Patterns are often invalid halfway through typing them, so the
+		// last valid one keeps filtering meanwhile
+		m.flash = ""
+		if err := m.csvViewer.FilterRowsRegexp(m.promptInput); err != nil {
+			m.flash = fmt.Sprintf("Invalid regex: %v", err)
+		}
+	case promptJSONSearch:
+		if m.treeViewer() != nil {
+			m.treeViewer().Search(m.promptInput)
+		}
+	}
+}
+
+// submitPrompt closes the prompt, running the action of prompts that only act on Enter
+func (m *Model) submitPrompt() {
+	switch m.prompt {
+	case promptCSVColumn:
+		if m.csvViewer != nil && m.promptInput != "" && !m.csvViewer.JumpToColumn(m.promptInput) {
+			m.flash = fmt.Sprintf("No column matching %q", m.promptInput)
+		}
+		m.promptInput = ""
+	case promptCSVExport:
+		if m.csvViewer != nil && m.promptInput != "" {
+			m.flash = exportCSVView(m.csvViewer, m.promptInput)
+		}
+		m.promptInput = ""
+	case promptJSONPath:
+		if viewer := m.treeViewer(); viewer != nil && m.promptInput != "" {
+			if _, err := viewer.FindByPath(m.promptInput); err != nil {
+				m.flash = fmt.Sprintf("Go to path failed: %v", err)
+			}
+		}
+		m.promptInput = ""
+	}
+	m.prompt = promptNone
+}
+
+// exportCSVView writes the CSV view to path and returns a message describing the result
+func exportCSVView(viewer *ui.CSVViewer, path string) string {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Sprintf("Export failed: %v", err)
+	}
+
+	err = viewer.ExportCSV(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Sprintf("Export failed: %v", err)
 	}
+	return fmt.Sprintf("Wrote %d rows to %s", viewer.RowCount(), path)
+}
+
+// cancelPrompt closes the prompt and undoes its effect
+func (m *Model) cancelPrompt() {
+	switch m.prompt {
+	case promptCSVFilter:
+		if m.csvViewer != nil {
+			m.csvViewer.ClearFilter()
+		}
+	case promptJSONSearch:
+		if m.treeViewer() != nil {
+			m.treeViewer().ClearSearch()
+		}
+	}
+	m.prompt = promptNone
+	m.promptInput = ""
 }
 
 // Update handles messages and user input
@@ -199,16 +967,48 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		key := msg.String()
-		if key == "q" || key == "ctrl+c" {
+		if key == "ctrl+c" {
+			return m, tea.Quit
+		}
+
+		// Route keys to the active prompt first
+		if m.prompt != promptNone {
+			m.handlePromptKey(msg)
+			m.layoutViewers()
+			return m, nil
+		}
+
+		if m.keys.Quit.Matches(key) {
 			return m, tea.Quit
 		}
 
-		// Handle viewer-specific keys
-		switch m.viewerType {
-		case TypeJSON, TypeJSONL:
-			m.handleJSONKeyMsg(key)
-		case TypeCSV:
-			m.handleCSVKeyMsg(key)
+		// Messages only last until the next key press
+		m.flash = ""
+
+		// Switch tabs, or handle viewer-specific keys
+		switch {
+		case m.keys.NextTab.Matches(key):
+			m.switchTab(1)
+		case m.keys.PrevTab.Matches(key):
+			m.switchTab(-1)
+		default:
+			switch m.activeViewerType() {
+			case TypeJSON, TypeJSONL, TypeYAML, TypeTOML:
+				m.handleJSONKeyMsg(key)
+			case TypeCSV, TypeTSV:
+				m.handleCSVKeyMsg(key)
+			}
+		}
+		// The footer may have grown or shrunk with the key's result
+		m.layoutViewers()
+		m.syncDiff()
+
+	case tea.MouseMsg:
+		// Leave the view alone while a prompt is being typed
+		if m.prompt == promptNone {
+			m.handleMouseMsg(msg)
+			m.layoutViewers()
+			m.syncDiff()
 		}
 
 	case tea.WindowSizeMsg:
@@ -216,33 +1016,199 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 
 		// Update viewers with new size
-		if m.jsonViewer != nil {
-			m.jsonViewer.SetViewportHeight(m.height - HeaderFooterSpace) // Subtract space for header/footer
+		m.layoutViewers()
+		m.syncDiff()
+
+	case FileChangedMsg:
+		// Keep watching, and reload unless the watcher itself failed
+		tab := m.tabs[msg.tab]
+		wait := waitForChangeCmd(msg.tab, tab.watcher)
+		if msg.error != nil {
+			m.flash = fmt.Sprintf("Error: %v", msg.error)
+			m.layoutViewers()
+			return m, wait
 		}
-		if m.csvViewer != nil {
-			m.csvViewer.SetViewport(m.width-HeaderFooterSpace, m.height-CSVBorderSpace) // Subtract space for borders and header/footer
+		return m, tea.Batch(loadSourceCmd(msg.tab, tab.filePath, nil, true), wait)
+
+	case LoadProgressMsg:
+		// Keep redrawing until the tab has loaded
+		if m.tabs[msg.tab].isLoading {
+			return m, progressTickCmd(msg.tab)
 		}
+		return m, nil
 
 	case FileLoadedMsg:
-		m.isLoading = false
+		tab := m.tabs[msg.tab]
+		tab.isLoading = false
 		if msg.error != nil {
-			m.errorMsg = fmt.Sprintf("Error: %v", msg.error)
+			if msg.reload && tab.errorMsg == "" {
+				// Keep showing the last version that parsed
+				m.flash = fmt.Sprintf("Reload failed: %v", msg.error)
+				m.layoutViewers()
+				return m, nil
+			}
+			tab.errorMsg = fmt.Sprintf("Error: %v", msg.error)
 			return m, nil
 		}
 
-		m.viewerType = msg.viewerType
-		if msg.viewerType == TypeJSON || msg.viewerType == TypeJSONL {
-			m.jsonViewer = msg.jsonViewer
-			m.jsonViewer.SetViewportHeight(m.height - HeaderFooterSpace)
-		} else if msg.viewerType == TypeCSV {
-			m.csvViewer = msg.csvViewer
-			m.csvViewer.SetViewport(m.width-HeaderFooterSpace, m.height-CSVBorderSpace)
+		prevJSON, prevCSV := tab.jsonViewer, tab.csvViewer
+		tab.errorMsg = ""
+		tab.rowDetail = nil // The row may have changed or gone
+		tab.schema = nil
+		tab.raw = nil
+		tab.stats = msg.stats
+		tab.viewerType = msg.viewerType
+		if msg.viewerType == TypeJSON || msg.viewerType == TypeJSONL || msg.viewerType == TypeYAML || msg.viewerType == TypeTOML {
+			tab.jsonViewer = msg.jsonViewer
+		} else if msg.viewerType == TypeCSV || msg.viewerType == TypeTSV {
+			tab.csvViewer = msg.csvViewer
+		}
+		m.layoutViewers()
+
+		// Carry the reading position over to the reloaded file
+		if msg.reload {
+			if prevJSON != nil && prevJSON != tab.jsonViewer {
+				tab.jsonViewer.RestoreState(prevJSON)
+			}
+			if prevCSV != nil && prevCSV != tab.csvViewer {
+				tab.csvViewer.RestoreState(prevCSV)
+			}
+		}
+		if m.diffing {
+			m.applyDiff()
 		}
 	}
 
 	return m, nil
 }
 
+// layoutViewers gives the viewers exactly the lines left between the title
+// and the footer. The alt-screen drops lines from the top of a view that is
+// taller than the terminal, so overshooting would scroll the title and the
+// CSV header out of sight.
+func (m *Model) layoutViewers() {
+	available := max(m.height-m.chromeLines()-lipgloss.Height(m.renderFooter()), 1)
+
+	// Every tab gets the active tab's size; switching tabs lays them out again
+	width := m.width
+	if m.diffing {
+		width = m.paneWidth()
+	}
+	for _, tab := range m.tabs {
+		tab.layout(width, available)
+	}
+}
+
+// layout sizes the tab's viewers to width by height
+func (t *fileTab) layout(width, height int) {
+	if t.jsonViewer != nil {
+		t.jsonViewer.SetViewportHeight(height)
+		t.jsonViewer.SetViewportWidth(width)
+	}
+	if t.rowDetail != nil {
+		t.rowDetail.SetViewportHeight(height)
+		t.rowDetail.SetViewportWidth(width)
+	}
+	if t.schema != nil {
+		t.schema.SetViewportHeight(height)
+		t.schema.SetViewportWidth(width)
+	}
+	if t.raw != nil {
+		t.raw.SetViewportHeight(height)
+		t.raw.SetViewportWidth(width)
+	}
+	if t.csvViewer != nil {
+		t.csvViewer.SetViewport(width-HeaderFooterSpace, height)
+	}
+}
+
+// chromeLines returns the lines the view spends outside the content and footer
+func (m Model) chromeLines() int {
+	if m.showTabBar() {
+		return ViewChromeLines + TabBarLines
+	}
+	return ViewChromeLines
+}
+
+// contentTopLine returns the screen line the content starts on
+func (m Model) contentTopLine() int {
+	if m.showTabBar() {
+		return ContentTopLine + TabBarLines
+	}
+	return ContentTopLine
+}
+
+// showTabBar reports whether several files are open, so a tab bar is shown
+func (m Model) showTabBar() bool {
+	return len(m.tabs) > 1
+}
+
+// tabLabel names a source briefly for the tab bar
+func tabLabel(source string) string {
+	switch {
+	case source == "":
+		return InputStdin
+	case loader.IsURL(source):
+		return path.Base(loader.URLPath(source))
+	default:
+		return filepath.Base(source)
+	}
+}
+
+// renderTabBar renders a numbered label for each open file, the active one
+// highlighted. A diff labels each pane above it instead.
+func (m Model) renderTabBar() string {
+	labels := make([]string, len(m.tabs))
+	for i, tab := range m.tabs {
+		label := fmt.Sprintf("%d %s", i+1, tabLabel(tab.filePath))
+		if i == m.active {
+			labels[i] = activeTabStyle.Render(label)
+		} else {
+			labels[i] = tabStyle.Render(label)
+		}
+		if m.diffing && i == 0 {
+			labels[i] = lipgloss.NewStyle().Width(m.paneWidth() + ansi.StringWidth(DiffSeparator)).
+				Render(ansi.Truncate(labels[i], m.paneWidth(), "…"))
+		}
+	}
+	return ansi.Truncate(lipgloss.JoinHorizontal(lipgloss.Top, labels...), m.width, "…")
+}
+
+// renderDiff renders the two documents of a diff side by side, or the first
+// error or loading message while they aren't both ready
+func (m Model) renderDiff() string {
+	for _, tab := range m.tabs {
+		if tab.errorMsg != "" {
+			return renderError(tab.errorMsg)
+		}
+	}
+	for _, tab := range m.tabs {
+		if tab.isLoading {
+			return renderLoading(tab.filePath, tab.progress)
+		}
+	}
+	if m.diff == nil {
+		return renderLoading("the diff", nil)
+	}
+
+	header := lipgloss.JoinHorizontal(lipgloss.Top,
+		titleStyle.Render(AppTitle),
+		lipgloss.NewStyle().PaddingLeft(2).Render(fmt.Sprintf("Diff: %s → %s", m.tabs[0].filePath, m.tabs[1].filePath)))
+
+	pane := lipgloss.NewStyle().Width(m.paneWidth())
+	left := strings.TrimSuffix(m.tabs[0].jsonViewer.Render(), "\n")
+	right := strings.TrimSuffix(m.tabs[1].jsonViewer.Render(), "\n")
+	height := max(lipgloss.Height(left), lipgloss.Height(right))
+	column := DiffSeparator
+	if ui.ActiveTheme().ASCII {
+		column = ASCIIDiffSeparator
+	}
+	separator := strings.TrimSuffix(strings.Repeat(column+"\n", height), "\n")
+	content := lipgloss.JoinHorizontal(lipgloss.Top, pane.Render(left), ui.SeparatorStyle.Render(separator), pane.Render(right))
+
+	return fmt.Sprintf("%s\n\n%s\n\n%s", header, content, m.renderFooter())
+}
+
 // renderError renders an error message
 func renderError(msg string) string {
 	return fmt.Sprintf("%s\n\n%s",
@@ -250,59 +1216,362 @@ func renderError(msg string) string {
 		lipgloss.NewStyle().Foreground(ErrorColor).Render(msg))
 }
 
-// renderLoading renders a loading message
-func renderLoading(path string) string {
-	return fmt.Sprintf("%s\n\nLoading %s...",
+// renderLoading renders a loading message, followed by how much of the
+// input has been read once there is any
+func renderLoading(path string, progress *LoadProgress) string {
+	message := fmt.Sprintf("%s\n\nLoading %s...",
 		titleStyle.Render(AppTitle),
 		path)
+	if read := progress.String(); read != "" {
+		message += "\n" + read
+	}
+	return message
 }
 
-// getControlsForViewer returns help text based on viewer type
-func getControlsForViewer(viewerType string) string {
+// getControlsForViewer returns help text based on viewer type, naming the
+// keys each action is bound to in keys
+func getControlsForViewer(viewerType string, keys KeyMap) string {
+	var entries []string
 	switch viewerType {
-	case TypeJSON, TypeJSONL:
-		return infoStyle.Render("↑/↓: Navigate | Space/Enter: Toggle | q: Quit")
-	case TypeCSV:
-		return infoStyle.Render("↑/↓/←/→: Navigate | Space/Enter: Toggle visibility | s: Sort | q: Quit")
+	case TypeJSON, TypeJSONL, TypeYAML, TypeTOML:
+		entries = []string{
+			controlEntry("Navigate", keys.MoveUp, keys.MoveDown),
+			controlEntry("Toggle", keys.Toggle),
+			controlEntry("Expand level/subtree", keys.ExpandLevel, keys.ExpandSubtree),
+			controlEntry("Expand/collapse all", keys.ExpandAll, keys.CollapseAll),
+			controlEntry("Types", keys.ToggleTypes),
+			controlEntry("Search", keys.Search),
+			controlEntry("Go to path", keys.GoToPath),
+			controlEntry("Copy path/JSON", keys.CopyPath, keys.CopyJSON),
+			controlEntry("Schema", keys.Schema),
+			controlEntry("Raw JSON", keys.RawView),
+			controlEntry("Quit", keys.Quit),
+		}
+	case TypeCSV, TypeTSV:
+		entries = []string{
+			controlEntry("Navigate", keys.MoveUp, keys.MoveDown, keys.MoveLeft, keys.MoveRight),
+			controlEntry("Cell detail", keys.CellDetail),
+			controlEntry("Column stats/values", keys.ColumnStats, keys.DistinctValues),
+			controlEntry("Toggle visibility", keys.ToggleColumn),
+			controlEntry("Invert/show all", keys.InvertColumns, keys.ShowAllColumns),
+			controlEntry("Sort", keys.Sort),
+			controlEntry("Isolate column", keys.IsolateColumn),
+			controlEntry("Column width", keys.WidenColumns, keys.NarrowColumns),
+			controlEntry("Fit/Freeze", keys.FitColumns, keys.FreezeColumns),
+			controlEntry("Row/column numbers", keys.RowNumbers, keys.Ruler),
+			controlEntry("Filter", keys.Filter),
+			controlEntry("Duplicates/unique", keys.Duplicates, keys.Unique),
+			controlEntry("Go to column", keys.GoToColumn),
+			controlEntry("Transpose", keys.Transpose),
+			controlEntry("Write view", keys.WriteView),
+			controlEntry("Row as JSON", keys.RowDetail),
+			controlEntry("Quit", keys.Quit),
+		}
+	default:
+		entries = []string{controlEntry("Quit", keys.Quit)}
+	}
+	entries = slices.DeleteFunc(entries, func(entry string) bool { return entry == "" })
+	return infoStyle.Render(strings.Join(entries, " | "))
+}
+
+// controlEntry renders one entry of the controls, the first key of each
+// action joined by slashes before the label, e.g. "x/X: Expand level/subtree".
+// It is empty when one of the actions has no key bound.
+func controlEntry(label string, actions ...Keys) string {
+	names := make([]string, len(actions))
+	for i, action := range actions {
+		if names[i] = action.Label(); names[i] == "" {
+			return ""
+		}
+	}
+	return strings.Join(names, "/") + ": " + label
+}
+
+// getStatusForViewer returns the status line shown above the controls: the
+// cursor position, followed by whatever search, filter or notice is active
+func (m Model) getStatusForViewer() string {
+	var segments []string
+	switch m.activeViewerType() {
+	case TypeJSON, TypeJSONL, TypeYAML, TypeTOML:
+		if m.raw != nil {
+			segments = append(segments, fmt.Sprintf("Line %d of %d", m.raw.FirstLine(), m.raw.LineCount()))
+			segments = append(segments, scrollIndicator(m.raw.ScrollPercent()))
+			segments = append(segments, fmt.Sprintf("Raw JSON (%s or %s: back to the tree)", m.keys.RawView.Label(), m.keys.Clear.Label()))
+			break
+		}
+		viewer := m.treeViewer()
+		if viewer == nil {
+			return ""
+		}
+		segments = append(segments, fmt.Sprintf("Node %d of %d (visible)",
+			viewer.CursorPosition(), viewer.VisibleNodeCount()))
+		segments = append(segments, scrollIndicator(viewer.ScrollPercent()))
+		if query := viewer.SearchQuery(); query != "" {
+			segments = append(segments, fmt.Sprintf("Search: %q | Match %d of %d (%s/%s: next/prev, %s to clear)",
+				query, viewer.CurrentMatch(), viewer.MatchCount(),
+				m.keys.NextMatch.Label(), m.keys.PrevMatch.Label(), m.keys.Clear.Label()))
+		} else if m.rowDetail != nil {
+			segments = append(segments, fmt.Sprintf("Row detail (%s: back to the table)", m.keys.Clear.Label()))
+		} else if m.schema != nil {
+			segments = append(segments, fmt.Sprintf("Schema (%s or %s: back to the values)", m.keys.Schema.Label(), m.keys.Clear.Label()))
+		} else if m.diff != nil {
+			segments = append(segments, fmt.Sprintf("Diff: %d added, %d removed, %d changed (%s: switch pane)",
+				m.diff.Added, m.diff.Removed, m.diff.Changed, m.keys.NextTab.Label()))
+		} else {
+			if skipped := len(viewer.Skipped); skipped == 1 {
+				segments = append(segments, "Skipped 1 malformed line")
+			} else if skipped > 1 {
+				segments = append(segments, fmt.Sprintf("Skipped %d malformed lines", skipped))
+			}
+			if duplicates := viewer.DuplicateKeys; duplicates == 1 {
+				segments = append(segments, "1 duplicate key")
+			} else if duplicates > 1 {
+				segments = append(segments, fmt.Sprintf("%d duplicate keys", duplicates))
+			}
+		}
+	case TypeCSV, TypeTSV:
+		if m.csvViewer == nil {
+			return ""
+		}
+		segments = append(segments, fmt.Sprintf("Row %d of %d", m.csvViewer.CursorPosition(), m.csvViewer.RowCount()))
+		segments = append(segments, scrollIndicator(m.csvViewer.ScrollPercent()))
+		if query := m.csvViewer.FilterQuery(); query != "" {
+			shown := fmt.Sprintf("%q", query)
+			if m.csvViewer.FilterIsRegexp() {
+				shown = "/" + query + "/"
+			}
+			segments = append(segments, fmt.Sprintf("Filter: %s matches %d of %d rows (%s to clear)",
+				shown, m.csvViewer.RowCount(), m.csvViewer.TotalRowCount(), m.keys.Clear.Label()))
+		}
+		if mode, col := m.csvViewer.DuplicateMode(); mode != ui.DuplicatesOff {
+			segments = append(segments, duplicateStatus(mode, col, m.csvViewer.Data().Headers, m.keys))
+		}
+		if m.csvViewer.Transposed() {
+			segments = append(segments, fmt.Sprintf("Transposed (%s: back to rows)", m.keys.Transpose.Label()))
+		}
+	default:
+		return ""
+	}
+	return infoStyle.Render(strings.Join(segments, " | "))
+}
+
+// duplicateStatus describes which rows a duplicate mode shows
+func duplicateStatus(mode ui.DuplicateMode, col int, headers []string, keys KeyMap) string {
+	key := "rows"
+	if col >= 0 && col < len(headers) {
+		key = fmt.Sprintf("%q values", headers[col])
+	}
+	if mode == ui.UniqueOnly {
+		return fmt.Sprintf("Unique %s (%s: next, %s to clear)", key, keys.Unique.Label(), keys.Clear.Label())
+	}
+	return fmt.Sprintf("Duplicate %s (%s: next, %s to clear)", key, keys.Duplicates.Label(), keys.Clear.Label())
+}
+
+// LoadStats describes a load: the bytes read from the input, after any
+// decompression, and the time taken to read and parse them
+type LoadStats struct {
+	Bytes    int64
+	Duration time.Duration
+}
+
+// String formats the stats for the header, e.g. "1.2 MB · parsed in 34ms"
+func (s LoadStats) String() string {
+	return fmt.Sprintf("%s · parsed in %s", formatBytes(s.Bytes), formatDuration(s.Duration))
+}
+
+// formatBytes formats a byte count in the largest unit it reaches, e.g. 512 B or 1.2 MB
+func formatBytes(n int64) string {
+	const unit, prefixes = 1024, "KMGT"
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, prefix := float64(n)/unit, 0
+	for value >= unit && prefix < len(prefixes)-1 {
+		value /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f %cB", value, prefixes[prefix])
+}
+
+// formatDuration formats a duration in milliseconds below a second, and in
+// seconds to a tenth above
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return "<1ms"
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
 	default:
-		return infoStyle.Render("q: Quit")
+		return fmt.Sprintf("%.1fs", d.Seconds())
 	}
 }
 
+// LoadProgress counts the bytes of an input read so far. The goroutine
+// loading it adds to the count while the view reads it.
+type LoadProgress struct {
+	read  atomic.Int64
+	total atomic.Int64 // Size of the input, or 0 when it isn't known
+}
+
+// Bytes returns how many bytes have been read
+func (p *LoadProgress) Bytes() int64 {
+	return p.read.Load()
+}
+
+// String formats the progress for the loading screen, e.g. "Loaded 45.0 MB
+// of 120.0 MB (37%)", or "" before anything has been read. Without a known
+// size it is just "Loaded 45.0 MB".
+func (p *LoadProgress) String() string {
+	if p == nil {
+		return ""
+	}
+	read, total := p.read.Load(), p.total.Load()
+	switch {
+	case read == 0:
+		return ""
+	case read == total:
+		return fmt.Sprintf("Loaded %s, preparing the view...", formatBytes(read))
+	case total > 0 && read < total:
+		return fmt.Sprintf("Loaded %s of %s (%d%%)", formatBytes(read), formatBytes(total), read*100/total)
+	default:
+		return fmt.Sprintf("Loaded %s", formatBytes(read))
+	}
+}
+
+// countingReader counts the bytes read through it into progress
+type countingReader struct {
+	io.Reader
+	progress *LoadProgress
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.progress.read.Add(int64(n))
+	return n, err
+}
+
+// scrollIndicator formats a viewer's scroll position like less does: a
+// percentage, or ALL when everything fits on screen
+func scrollIndicator(percent int, fits bool) string {
+	if fits {
+		return "ALL"
+	}
+	return fmt.Sprintf("%d%%", percent)
+}
+
+// renderPrompt renders the active text prompt
+func (m Model) renderPrompt() string {
+	label := ""
+	switch m.prompt {
+	case promptCSVFilter:
+		label = "/"
+		if m.promptRegex {
+			label = "Regex /"
+		}
+	case promptJSONSearch:
+		label = "/"
+	case promptCSVColumn:
+		label = "Go to column: "
+	case promptCSVExport:
+		label = "Write CSV to: "
+	case promptJSONPath:
+		label = "Go to path: "
+	}
+	return infoStyle.Render(label + m.promptInput + "█")
+}
+
 func (m Model) View() string {
+	// Other tabs stay reachable while this one failed or is loading
+	tabBar := ""
+	if m.showTabBar() {
+		tabBar = m.renderTabBar() + "\n"
+	}
+
+	if m.diffing {
+		return tabBar + m.renderDiff()
+	}
+
 	if m.errorMsg != "" {
-		return renderError(m.errorMsg)
+		return tabBar + renderError(m.errorMsg)
 	}
 
 	if m.isLoading {
-		return renderLoading(m.filePath)
+		return tabBar + renderLoading(m.filePath, m.progress)
 	}
 
 	// Create header with title and file info
+	info := fmt.Sprintf("File: %s | Type: %s | %s", m.filePath, m.viewerType, m.stats)
+	if m.showTabBar() {
+		info += fmt.Sprintf(" | Tab %d of %d", m.active+1, len(m.tabs))
+	}
 	header := lipgloss.JoinHorizontal(lipgloss.Top,
 		titleStyle.Render(AppTitle),
-		lipgloss.NewStyle().PaddingLeft(2).Render(fmt.Sprintf("File: %s | Type: %s", m.filePath, m.viewerType)))
+		lipgloss.NewStyle().PaddingLeft(2).Render(info))
 
 	// Create content based on viewer type
 	var content string
-	switch m.viewerType {
-	case TypeJSON, TypeJSONL:
-		if m.jsonViewer != nil {
-			content = m.jsonViewer.Render()
+	switch m.activeViewerType() {
+	case TypeJSON, TypeJSONL, TypeYAML, TypeTOML:
+		if m.raw != nil {
+			content = m.raw.Render()
+		} else if m.treeViewer() != nil {
+			content = m.treeViewer().Render()
 		}
-	case TypeCSV:
+	case TypeCSV, TypeTSV:
 		if m.csvViewer != nil {
 			content = m.csvViewer.Render()
 		}
+	case TypeEmpty:
+		content = infoStyle.Render(NoDataMessage)
 	default:
 		content = "No content to display"
 	}
 
-	// Get controls for current viewer
-	controls := getControlsForViewer(m.viewerType)
+	// Combine all elements; layoutViewers sized the content to fit between them
+	content = strings.TrimSuffix(content, "\n")
+	return fmt.Sprintf("%s%s\n\n%s\n\n%s", tabBar, header, content, m.renderFooter())
+}
+
+// renderFooter renders the flash message, status line and controls (or the open prompt)
+func (m Model) renderFooter() string {
+	// Get controls for current viewer, or the prompt while one is open
+	footer := getControlsForViewer(m.activeViewerType(), m.keys)
+	if m.prompt != promptNone {
+		footer = m.renderPrompt()
+	}
+	if status := m.getStatusForViewer(); status != "" {
+		footer = status + "\n" + footer
+	}
+	if cell := m.cellValueLine(); cell != "" {
+		footer = cell + "\n" + footer
+	}
+	if m.flash != "" {
+		footer = infoStyle.Render(m.flash) + "\n" + footer
+	}
+	return footer
+}
+
+// cellValueLine shows the full value of the table cell under the cursor after
+// its column's name, on one line cut to the terminal width, or returns ""
+// when no cell is selected
+func (m Model) cellValueLine() string {
+	switch m.activeViewerType() {
+	case TypeCSV, TypeTSV:
+	default:
+		return ""
+	}
+	if m.csvViewer == nil || m.csvViewer.RowCount() == 0 {
+		return ""
+	}
 
-	// Combine all elements
-	return fmt.Sprintf("%s\n\n%s\n\n%s", header, content, controls)
+	// Multi-line values are joined so the footer keeps its height
+	value := strings.NewReplacer("\r\n", " ", "\n", " ", "\t", " ").Replace(m.csvViewer.CurrentCellValue())
+	line := m.csvViewer.CurrentColumnName() + ": " + value
+	if m.width > 0 {
+		// The footer style's left padding takes a cell
+		line = ansi.Truncate(line, m.width-1, "...")
+	}
+	return infoStyle.Render(line)
 }
 
 // testCSVViewer tests the CSV viewer alignment
@@ -338,81 +1607,409 @@ func testCSVViewer() {
 	}
 }
 
-// readDataFromSource reads data from either a file or stdin
-func readDataFromSource(source string) ([]byte, error) {
+// openSource opens a file, an http(s) URL or stdin for streaming reads
+func openSource(source string) (io.ReadCloser, error) {
+	if loader.IsURL(source) {
+		return loader.OpenURL(source)
+	}
+
 	// Read from stdin if specified
 	if source == InputStdin {
-		return io.ReadAll(os.Stdin)
+		// Piped input has no extension, so gzip is recognized by its magic bytes
+		content, err := loader.Decompress(os.Stdin, false)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(content), nil
 	}
 
-	// Otherwise read from file
-	return os.ReadFile(source)
+	// Otherwise open the file
+	fileLoader, err := loader.NewFileLoader(source)
+	if err != nil {
+		return nil, err
+	}
+	if err := fileLoader.Open(); err != nil {
+		return nil, err
+	}
+	return fileLoader, nil
 }
 
-// runNonInteractiveMode shows content without TUI
-func runNonInteractiveMode(source string) {
-	// Get the format flag value
-	formatFlag := ""
-	flag.Visit(func(f *flag.Flag) {
-		if f.Name == "format" {
-			formatFlag = f.Value.String()
+// loadSource opens the source, detects its format from a leading sample and
+// parses it into a viewer. CSV input is parsed as a stream, so memory holds the
+// parsed rows and a small detection buffer rather than the raw bytes as well.
+// JSON input still needs the whole document in memory before parsing.
+// When progress isn't nil it counts the bytes read as loading goes on.
+func loadSource(source string, opts LoadOptions, progress *LoadProgress) (string, *ui.JSONViewer, *ui.CSVViewer, error) {
+	reader, err := openSource(source)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	defer reader.Close()
+
+	var input io.Reader = reader
+	if progress != nil {
+		// Only the size of an uncompressed file says how much is left to read
+		if file, ok := reader.(*loader.FileLoader); ok && !file.Compressed() {
+			progress.total.Store(file.GetFileInfo().Size)
 		}
-	})
+		input = &countingReader{Reader: reader, progress: progress}
+	}
 
-	data, err := readDataFromSource(source)
+	// Legacy exports, e.g. Latin-1 CSV, are transcoded to UTF-8 before parsing
+	decoded, err := loader.DecodeText(input, opts.Encoding)
 	if err != nil {
-		fmt.Printf("Error reading input: %v\n", err)
-		os.Exit(1)
+		return "", nil, nil, err
+	}
+	buffered := bufio.NewReaderSize(decoded, parser.DetectSampleSize)
+	fileType := opts.Format
+	delimiter := ','
+
+	// Empty and whitespace-only input has nothing to show in any format
+	if blank, err := skipBlankSamples(buffered); err != nil {
+		return "", nil, nil, err
+	} else if blank {
+		return TypeEmpty, nil, nil, nil
 	}
 
-	fileType, jsonViewer, csvViewer, err := parseFile(data, formatFlag)
+	// Servers name the format of what they send
+	if body, ok := reader.(*loader.URLBody); ok && fileType == "" {
+		fileType = parser.MediaTypeFileType(body.MediaType)
+	}
+
+	// Some formats are only recognizable by their extension
+	if fileType == "" && source != InputStdin {
+		name := source
+		if loader.IsURL(source) {
+			name = loader.URLPath(source)
+		}
+		fileType = parser.ExtensionFileType(filepath.Ext(loader.TrimGzipExtension(name)))
+	}
+
+	// Auto-detect format from the leading bytes if not forced
+	if fileType == "" {
+		sample, err := buffered.Peek(parser.DetectSampleSize)
+		if err != nil && err != io.EOF {
+			return "", nil, nil, err
+		}
+
+		// A full buffer means the input likely continues past the sample
+		var detected rune
+		fileType, detected = parser.DetectSampleType(sample, err == nil)
+		if detected != 0 {
+			delimiter = detected
+		}
+	}
+
+	if fileType == TypeTSV {
+		delimiter = '\t'
+	}
+
+	if fileType == TypeCSV || fileType == TypeTSV {
+		if opts.Query != "" {
+			return "", nil, nil, fmt.Errorf("--query only applies to JSON, JSONL, YAML and TOML input")
+		}
+		csvParser := parser.NewCSVParser()
+		csvParser.Comma = delimiter
+		csvParser.Comment = opts.Comment
+		csvParser.TrimSpace = opts.TrimSpace
+		csvParser.Header = opts.Header
+		csvData, err := csvParser.ParseStream(buffered)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return fileType, nil, newCSVViewer(csvData, opts), nil
+	}
+
+	// Other formats are parsed from the complete input
+	data, err := io.ReadAll(buffered)
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
+		return "", nil, nil, err
+	}
+	opts.Format = fileType
+	return parseFile(data, opts)
+}
+
+// runNonInteractiveMode shows content without TUI
+func runNonInteractiveMode(source string) {
+	opts := loadOptionsFromFlags()
+	fileType, jsonViewer, csvViewer, err := loadSource(source, opts, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
 	switch fileType {
-	case TypeJSON, TypeJSONL:
+	case TypeJSON, TypeJSONL, TypeYAML, TypeTOML:
+		for _, skipped := range jsonViewer.Skipped {
+			fmt.Fprintf(os.Stderr, "Warning: skipped %v\n", skipped)
+		}
+		if duplicates := jsonViewer.DuplicateKeys; duplicates == 1 {
+			fmt.Fprintln(os.Stderr, "Warning: 1 duplicate key, both occurrences kept")
+		} else if duplicates > 1 {
+			fmt.Fprintf(os.Stderr, "Warning: %d duplicate keys, every occurrence kept\n", duplicates)
+		}
+
+		// A query prints just the matched value, like jq
+		if opts.Query != "" {
+			data, err := jsonViewer.Root().ToJSON()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
 		jsonViewer.SetViewportHeight(DefaultHeight - HeaderFooterSpace)
 		fmt.Println(jsonViewer.Render())
 
-	case TypeCSV:
+	case TypeCSV, TypeTSV:
 		csvViewer.SetViewport(DefaultWidth-HeaderFooterSpace, DefaultHeight-CSVBorderSpace)
 		fmt.Println(csvViewer.Render())
+
+	case TypeEmpty:
+		fmt.Println(NoDataMessage)
+	}
+}
+
+// runConvertMode parses the source and writes it to output in the target format
+func runConvertMode(source, target, output, indent string, flatten bool) {
+	fileType, jsonViewer, csvViewer, err := loadSource(source, loadOptionsFromFlags(), nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Default to the input's own format
+	if target == "" {
+		target = fileType
+	}
+
+	// Open the output destination
+	var out io.Writer = os.Stdout
+	if output != "" && output != OutputStdout {
+		file, err := os.Create(output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating output: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := writeConverted(out, fileType, jsonViewer, csvViewer, target, indent, flatten); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// writeConverted serializes the parsed data into the target format. flatten
+// spreads nested objects over dotted columns when writing trees as tables.
+func writeConverted(out io.Writer, fileType string, jsonViewer *ui.JSONViewer, csvViewer *ui.CSVViewer, target, indent string, flatten bool) error {
+	switch fileType {
+	case TypeJSON, TypeJSONL, TypeYAML, TypeTOML:
+		root := jsonViewer.Root()
+		switch target {
+		case writer.FormatJSON:
+			// Serialize from the tree so keys keep their original order
+			return writer.WriteJSONIndent(out, root.RawValue(), indent)
+		case writer.FormatJSONL:
+			return writer.WriteJSONL(out, writer.NodeValues(root))
+		case writer.FormatCSV, writer.FormatTSV, writer.FormatMarkdown:
+			toCSVData := writer.NodeToCSVData
+			if flatten {
+				toCSVData = writer.NodeToFlatCSVData
+			}
+			csvData, err := toCSVData(root)
+			if err != nil {
+				return err
+			}
+			return writeTable(out, csvData, target)
+		}
+
+	case TypeCSV, TypeTSV:
+		csvData := csvViewer.Data()
+		switch target {
+		case writer.FormatJSON:
+			return writer.WriteJSONIndent(out, writer.CSVToValues(csvData), indent)
+		case writer.FormatJSONL:
+			return writer.WriteJSONL(out, writer.CSVToValues(csvData))
+		case writer.FormatCSV, writer.FormatTSV, writer.FormatMarkdown:
+			return writeTable(out, csvData, target)
+		}
+	}
+
+	// Empty input converts to empty output
+	if fileType == TypeEmpty {
+		return nil
+	}
+
+	return fmt.Errorf("cannot convert %s to %s", fileType, target)
+}
+
+// writeTable writes tabular data in one of the table output formats
+func writeTable(out io.Writer, data *parser.CSVData, target string) error {
+	switch target {
+	case writer.FormatCSV:
+		return writer.WriteCSV(out, data)
+	case writer.FormatTSV:
+		return writer.WriteTSV(out, data)
+	default:
+		return writer.WriteMarkdown(out, data)
 	}
 }
 
 // printHelp prints usage information
 func printHelp() {
-	fmt.Printf("Usage: %s [OPTIONS]\n\n", os.Args[0])
-	fmt.Println("A TUI file/text visualizer for JSON, CSV, and other formats.")
+	fmt.Printf("Usage: %s [OPTIONS] [FILE...]\n\n", os.Args[0])
+	fmt.Println("A TUI file/text visualizer for JSON, CSV, YAML, TOML, and other formats.")
 	fmt.Println("\nOptions:")
 	flag.PrintDefaults()
 	fmt.Println("\nExamples:")
 	fmt.Println("  # View a file interactively")
 	fmt.Println("  tablux --file data.json")
+	fmt.Println("\n  # Open several files, each in its own tab")
+	fmt.Println("  tablux orders.csv customers.json")
+	fmt.Println("\n  # Compare two versions of a config side by side")
+	fmt.Println("  tablux --diff old.json new.json")
 	fmt.Println("\n  # Process stdin input")
 	fmt.Println("  cat data.csv | tablux")
 	fmt.Println("\n  # Force specific format")
 	fmt.Println("  cat data.txt | tablux --format json")
 	fmt.Println("\n  # Output to stdout (non-interactive)")
 	fmt.Println("  tablux --file data.json --no-interactive")
-	fmt.Println("\nKeyboard controls:")
+	fmt.Println("\n  # Convert CSV to JSON")
+	fmt.Println("  tablux --file data.csv --to json --output data.json")
+	fmt.Println("\n  # Minify JSON")
+	fmt.Println("  tablux --file data.json --to json --indent 0")
+	fmt.Println("\n  # Print one value from a document")
+	fmt.Println("  tablux --file data.json --query '.users[2].name' --no-interactive")
+	fmt.Println("\n  # Use a built-in or custom color theme")
+	fmt.Println("  tablux --file data.json --theme solarized")
+	fmt.Println("\n  # Reload whenever the file is rewritten")
+	fmt.Println("  tablux --file export.csv --watch")
+	fmt.Println("\nKeyboard controls (the defaults; --keymap rebinds them):")
 	fmt.Println("  q, Ctrl+C: Quit")
-	fmt.Println("  ↑/↓: Navigate")
-	fmt.Println("  Space/Enter: Toggle expand/collapse (JSON) or column visibility (CSV)")
+	fmt.Println("  Tab/Shift+Tab: Switch to the next/previous file when several are open, or between the panes of a diff")
+	fmt.Println("  ↑/↓ or j/k: Navigate")
+	fmt.Println("  ←/→ or h/l: Move between columns (CSV), scroll long lines sideways (JSON)")
+	fmt.Println("  Home/g, End/G: Jump to first/last element")
+	fmt.Println("  Space/Enter: Toggle expand/collapse (JSON only)")
+	fmt.Println("  x/X: Expand the current node one level / its whole subtree (JSON only)")
+	fmt.Println("  E/C: Expand/collapse every node (JSON only)")
+	fmt.Println("  Enter: Show the selected cell's full value (CSV only)")
+	fmt.Println("  v: Toggle column visibility (CSV only)")
+	fmt.Println("  i/a: Invert column visibility / show all columns (CSV only)")
 	fmt.Println("  s: Sort column (CSV only)")
+	fmt.Println("  +/-: Widen/narrow columns (CSV only)")
+	fmt.Println("  o: Show only the current column, again to restore (CSV only)")
+	fmt.Println("  #: Toggle row numbers (CSV only)")
+	fmt.Println("  R: Toggle a ruler of column numbers above the header (CSV only)")
+	fmt.Println("  :: Jump to a column by name (CSV), or to a node by path such as .users[2].name (JSON)")
+	fmt.Println("  T: Transpose the table, showing each column as a line and each row as a column (CSV only)")
+	fmt.Println("  w: Write the filtered, sorted, visible columns to a CSV file (CSV only)")
+	fmt.Println("  r: Show the current row as a JSON object, Esc: back to the table (CSV only)")
+	fmt.Println("  /: Search keys and values (JSON) or filter rows (CSV), column:value to match one column, Esc: Clear")
+	fmt.Println("  Ctrl+R: Switch the CSV filter prompt between text and regular expressions")
+	fmt.Println("  n/N: Next/previous search match (JSON only)")
+	fmt.Println("  y: Copy the current node's path to the clipboard (JSON only)")
+	fmt.Println("  Y: Copy the current node and its children to the clipboard as JSON (JSON only)")
+	fmt.Println("  t: Toggle type annotations (JSON only)")
+	fmt.Println("  r: Show the document as pretty-printed JSON text, r/Esc: back to the tree (JSON only)")
+	fmt.Println("\nMouse:")
+	fmt.Println("  Click: Select a cell or node, click a node's ▼/► to expand/collapse it")
+	fmt.Println("  Wheel: Scroll the view without moving the cursor")
+}
+
+// fileList collects the values of a flag given once per file
+type fileList []string
+
+func (f *fileList) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *fileList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
 }
 
 func main() {
 	// Parse command-line flags
-	filePath := flag.String("file", "", "Path to the file to open (omit to use stdin)")
+	var files fileList
+	flag.Var(&files, "file", "Path or http(s) URL of a file to open, repeatable or given as arguments to open each in a tab (omit to use stdin)")
 	noInteractive := flag.Bool("no-interactive", false, "Run in non-interactive mode")
 	testCSV := flag.Bool("test-csv", false, "Run CSV viewer test")
-	format := flag.String("format", "", "Force a specific format: json, jsonl, or csv")
+	format := flag.String("format", "", "Force a specific format: json, jsonl, csv, tsv, yaml, or toml")
+	to := flag.String("to", "", "Convert the input to this format: json, jsonl, csv, tsv, or markdown (implies --no-interactive)")
+	output := flag.String("output", "", "Write converted output to this file, or - for stdout (implies --no-interactive)")
+	flatten := flag.Bool("flatten", false, "Flatten nested objects into dotted columns (e.g. user.name) for --to csv, tsv or markdown")
+	indent := flag.Int("indent", len(writer.DefaultIndent), "Spaces per indentation level for --to json, or 0 to minify")
+	flag.Int("max-col-width", ui.DefaultColumnMaxWidth, "Width at which CSV cells are truncated")
+	flag.Bool("fit", false, "Size CSV columns to fill the terminal width, by how much each one holds")
+	flag.Bool("thousands", false, "Group the digits of numbers in threes with commas, e.g. 1,234,567")
+	flag.Int("decimals", -1, "Round or pad numbers to this many decimal places (default as many as each needs)")
+	flag.Bool("scientific", false, "Write numbers in scientific notation, e.g. 1.5e+06")
+	flag.String("query", "", "Narrow JSON, YAML or TOML input to a path such as .users[2].name or .items[].id")
+	expandDepth := flag.Int("expand-depth", 0, "Open JSON, YAML and TOML trees expanded this many levels deep, e.g. 2 for the top two levels (default all of them)")
+	collapsed := flag.Bool("collapsed", false, "Open trees with only the root expanded, the same as --expand-depth 1")
+	encodingName := flag.String("encoding", "", "Character set of the input, e.g. latin1, windows-1252 or utf-16 (default UTF-8, with UTF-16 and windows-1252 detected)")
+	comment := flag.String("comment", string(parser.DefaultComment), "Character starting CSV comment lines to skip, or \"\" to keep every line")
+	headerMode := flag.String("header", parser.HeaderAlways.String(), "Whether the first CSV row names the columns: always, never or auto (guessed from the rows below it)")
+	noHeader := flag.Bool("no-header", false, "Read the first CSV row as data, under generated Column N headers, the same as --header never")
+	flag.Bool("trim", false, "Strip the whitespace around CSV and TSV fields, e.g. \"a , b\" reads as a and b")
+	flag.Bool("lazy", false, "Build JSON tree nodes only when expanded (automatic for inputs over 50 MB)")
+	flag.Bool("lenient", false, "Skip JSONL lines that fail to parse instead of stopping at the first")
+	flag.Bool("wrap", false, "Wrap navigation around from the last row, column or node to the first")
+	diff := flag.Bool("diff", false, "Compare two JSON, JSONL, YAML or TOML files side by side, e.g. --diff old.json new.json")
+	watch := flag.Bool("watch", false, "Reload the file whenever it changes (interactive mode only)")
+	themeName := flag.String("theme", "", "Color theme: auto, dark, light, solarized, or a JSON/TOML theme file (default auto in interactive mode)")
+	keymapPath := flag.String("keymap", "", "JSON file remapping keys, e.g. {\"toggle_column\": \"x\"}")
+	ascii := flag.Bool("ascii", false, "Draw tree symbols and table borders with ASCII characters only")
+	zebra := flag.Bool("zebra", false, "Shade every other CSV row to make wide tables easier to follow")
+	symbols := flag.Bool("symbols", false, "Show tree booleans as ✓/✗ and nulls as ∅ instead of the words")
+	noColor := flag.Bool("no-color", false, "Disable colors (also enabled by the NO_COLOR environment variable)")
 	help := flag.Bool("help", false, "Show usage information")
 	flag.Parse()
 
+	// Match the terminal background when browsing interactively; static
+	// output has no terminal to ask, so it keeps the default theme
+	interactive := !*noInteractive && *to == "" && *output == "" && !*testCSV
+	if *themeName == "" && interactive {
+		*themeName = ui.AutoThemeName
+	}
+
+	// Apply the requested theme before colors may be stripped below
+	if *themeName != "" {
+		theme, err := resolveTheme(*themeName)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		applyTheme(theme)
+	}
+
+	// Swap box-drawing and arrow symbols for ASCII on terminals that lack them
+	if *ascii {
+		theme := ui.ActiveTheme()
+		theme.ASCII = true
+		applyTheme(theme)
+	}
+	if *zebra {
+		theme := ui.ActiveTheme()
+		theme.Zebra = true
+		applyTheme(theme)
+	}
+	if *symbols {
+		theme := ui.ActiveTheme()
+		theme.ValueSymbols = true
+		applyTheme(theme)
+	}
+
+	// Honor --no-color and the NO_COLOR convention (https://no-color.org)
+	colorless := *noColor || os.Getenv("NO_COLOR") != ""
+	if colorless {
+		disableColors()
+	}
+
 	// Show help if requested
 	if *help {
 		printHelp()
@@ -426,43 +2023,160 @@ func main() {
 	}
 
 	// Validate format if provided
-	if *format != "" && *format != TypeJSON && *format != TypeJSONL && *format != TypeCSV {
-		fmt.Printf("Invalid format: %s. Use json, jsonl, or csv.\n", *format)
+	if *format != "" && *format != TypeJSON && *format != TypeJSONL && *format != TypeCSV && *format != TypeTSV && *format != TypeYAML && *format != TypeTOML {
+		fmt.Printf("Invalid format: %s. Use json, jsonl, csv, tsv, yaml, or toml.\n", *format)
+		os.Exit(1)
+	}
+
+	// Validate conversion target if provided
+	if *to != "" && *to != writer.FormatJSON && *to != writer.FormatJSONL && *to != writer.FormatCSV && *to != writer.FormatTSV && *to != writer.FormatMarkdown {
+		fmt.Printf("Invalid output format: %s. Use json, jsonl, csv, tsv, or markdown.\n", *to)
+		os.Exit(1)
+	}
+
+	if *indent < 0 {
+		fmt.Printf("Invalid indent: %d. Use 0 or more spaces.\n", *indent)
+		os.Exit(1)
+	}
+
+	if *expandDepth < 0 {
+		fmt.Printf("Invalid expand depth: %d. Use 1 or more levels, or 0 for all of them.\n", *expandDepth)
+		os.Exit(1)
+	}
+
+	if _, err := parser.ParseHeaderMode(*headerMode); err != nil {
+		fmt.Printf("Invalid header mode: %s. Use always, never, or auto.\n", *headerMode)
+		os.Exit(1)
+	}
+
+	// Resolve the shorthand flags into the ones they stand for, which
+	// loadOptionsFromFlags reads: --no-header overrides --header, while an
+	// explicit --expand-depth overrides --collapsed
+	if *noHeader {
+		flag.Set("header", parser.HeaderNever.String())
+	}
+	if *collapsed && !flagPassed("expand-depth") {
+		flag.Set("expand-depth", "1")
+	}
+
+	if !validComment(*comment) {
+		fmt.Printf("Invalid comment character: %q. Use a single character other than a quote or line break, or \"\" for none.\n", *comment)
 		os.Exit(1)
 	}
 
-	// Determine input source
-	source := *filePath
+	if *encodingName != "" {
+		if _, err := loader.LookupEncoding(*encodingName); err != nil {
+			fmt.Printf("Invalid encoding: %s. Use a name such as utf-8, latin1, windows-1252 or utf-16.\n", *encodingName)
+			os.Exit(1)
+		}
+	}
+
+	// Determine input sources, from --file flags and then arguments
+	sources := append(files, flag.Args()...)
 
 	// Check if we should use stdin
 	stat, _ := os.Stdin.Stat()
 	if (stat.Mode() & os.ModeCharDevice) == 0 {
 		// Data is being piped to stdin
-		source = InputStdin
-	} else if source == "" {
+		sources = fileList{InputStdin}
+	} else if len(sources) == 0 {
 		// No input source provided
 		fmt.Println("No input provided. Use --file flag or pipe data to stdin.")
 		fmt.Println("Run with --help for usage information.")
 		os.Exit(1)
 	}
 
-	// Run in non-interactive mode if requested
+	// A diff needs both documents and a terminal to lay them out side by side
+	if *diff {
+		if len(sources) != 2 || sources[0] == InputStdin {
+			fmt.Println("Error: --diff compares exactly two files, e.g. --diff old.json new.json")
+			os.Exit(1)
+		}
+		if !interactive {
+			fmt.Println("Error: --diff is only available in interactive mode.")
+			os.Exit(1)
+		}
+	}
+
+	// Static output without colors should be plain text, free of any escape codes
+	if colorless && *noInteractive {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+
+	// Convert and write out if an output format or destination is given
+	if *to != "" || *output != "" {
+		if len(sources) > 1 {
+			fmt.Println("Error: --to and --output convert one file at a time.")
+			os.Exit(1)
+		}
+		runConvertMode(sources[0], *to, *output, strings.Repeat(" ", *indent), *flatten)
+		return
+	}
+
+	// Run in non-interactive mode if requested, printing each file in turn
+	// under a header when there are several, like head does
 	if *noInteractive {
-		runNonInteractiveMode(source)
+		for i, source := range sources {
+			if len(sources) > 1 {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("==> %s <==\n", source)
+			}
+			runNonInteractiveMode(source)
+		}
 		return
 	}
 
-	// Create initial model
-	m := Model{
-		title:     AppName,
-		filePath:  source,
-		isLoading: true,
+	// Load custom key bindings over the defaults
+	keys := DefaultKeyMap()
+	if *keymapPath != "" {
+		var err error
+		if keys, err = loadKeyMapFile(*keymapPath); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	// Run interactive mode
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	// Create initial model with a tab per file
+	m := newModel(sources, keys)
+	m.diffing = *diff
+
+	// Watch the input files for changes if requested
+	if *watch {
+		for _, tab := range m.tabs {
+			if tab.filePath == InputStdin {
+				fmt.Println("Error: --watch needs a file; stdin can't be watched.")
+				os.Exit(1)
+			}
+			if loader.IsURL(tab.filePath) {
+				fmt.Println("Error: --watch needs a file; URLs can't be watched.")
+				os.Exit(1)
+			}
+			watcher, err := loader.NewFileWatcher(tab.filePath)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer watcher.Close()
+			tab.watcher = watcher
+		}
+	}
+
+	// Run interactive mode. Piped data takes up stdin, so keys are read
+	// from the terminal instead, the way less does it.
+	source := sources[0]
+	programOptions := []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
+	if source == InputStdin {
+		programOptions = append(programOptions, tea.WithInputTTY())
+	}
+	p := tea.NewProgram(m, programOptions...)
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running program: %v\n", err)
+		var pathErr *os.PathError
+		if source == InputStdin && errors.As(err, &pathErr) {
+			fmt.Println("Piped input is browsed with keys read from the terminal, but none is available. Use --no-interactive to print it instead.")
+		}
 		os.Exit(1)
 	}
 }
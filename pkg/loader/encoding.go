@@ -0,0 +1,76 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// encodingSampleSize is how many leading bytes are checked for valid UTF-8
+// when the encoding is detected
+const encodingSampleSize = 4096
+
+// utf16LEBOM and utf16BEBOM are the byte order marks of UTF-16 text
+var (
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// LookupEncoding returns the character set called name, e.g. latin1,
+// windows-1252, shift_jis or utf-16le, using the names web browsers accept
+func LookupEncoding(name string) (encoding.Encoding, error) {
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	return enc, nil
+}
+
+// DecodeText wraps r so text in the named encoding reads as UTF-8. With no
+// name the encoding is detected: UTF-16 by its byte order mark, and
+// windows-1252 (a superset of Latin-1) when the leading bytes aren't valid
+// UTF-8. Anything else passes through unchanged.
+func DecodeText(r io.Reader, name string) (io.Reader, error) {
+	if name != "" {
+		enc, err := LookupEncoding(name)
+		if err != nil {
+			return nil, err
+		}
+		return enc.NewDecoder().Reader(r), nil
+	}
+
+	buffered := bufio.NewReaderSize(r, encodingSampleSize)
+	sample, err := buffered.Peek(encodingSampleSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(sample, utf16LEBOM), bytes.HasPrefix(sample, utf16BEBOM):
+		utf16 := unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+		return utf16.NewDecoder().Reader(buffered), nil
+	case !looksUTF8(sample, err == nil):
+		enc, _ := LookupEncoding("windows-1252")
+		return enc.NewDecoder().Reader(buffered), nil
+	}
+	return buffered, nil
+}
+
+// looksUTF8 reports whether sample is valid UTF-8. When it was cut short of
+// the input, a rune split by its end still counts as valid.
+func looksUTF8(sample []byte, truncated bool) bool {
+	for len(sample) > 0 {
+		r, size := utf8.DecodeRune(sample)
+		if r == utf8.RuneError && size == 1 {
+			return truncated && !utf8.FullRune(sample)
+		}
+		sample = sample[size:]
+	}
+	return true
+}
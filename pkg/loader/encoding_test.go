@@ -0,0 +1,89 @@
+package loader
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("../../test/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// decodeString decodes data in the named encoding, or a detected one
+func decodeString(t *testing.T, data []byte, name string) string {
+	t.Helper()
+	r, err := DecodeText(bytes.NewReader(data), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(decoded)
+}
+
+func TestDecodeLatin1(t *testing.T) {
+	const want = "name,city\nJosé,Málaga\nRenée,Zürich\n"
+	data := readFixture(t, "latin1.csv")
+	for _, name := range []string{"", "latin1"} {
+		if got := decodeString(t, data, name); got != want {
+			t.Errorf("encoding %q: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestDecodeUTF16(t *testing.T) {
+	const want = "id,name\n1,José\n"
+	var le, be []byte
+	for _, r := range want {
+		le = append(le, byte(r), byte(r>>8))
+		be = append(be, byte(r>>8), byte(r))
+	}
+	for name, data := range map[string][]byte{
+		"little endian": append([]byte{0xFF, 0xFE}, le...),
+		"big endian":    append([]byte{0xFE, 0xFF}, be...),
+	} {
+		if got := decodeString(t, data, ""); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestDecodeUTF8PassesThrough(t *testing.T) {
+	// The sample ends halfway through the é, which must not be mistaken
+	// for windows-1252
+	data := strings.Repeat("a", encodingSampleSize-1) + "é,z\n"
+	if got := decodeString(t, []byte(data), ""); got != data {
+		t.Errorf("UTF-8 input changed when decoded, ending in %q", got[len(got)-8:])
+	}
+}
+
+func TestLooksUTF8(t *testing.T) {
+	truncatedRune := []byte("abc\xc3")
+	for _, test := range []struct {
+		name      string
+		sample    []byte
+		truncated bool
+		want      bool
+	}{
+		{"empty", nil, false, true},
+		{"ascii and accents", []byte("Zürich"), false, true},
+		{"latin1 byte", []byte("Z\xfcrich"), true, false},
+		{"rune cut by the sample", truncatedRune, true, true},
+		{"rune cut by the input", truncatedRune, false, false},
+		{"invalid byte before the end", []byte("ab\xc3z"), true, false},
+	} {
+		if got := looksUTF8(test.sample, test.truncated); got != test.want {
+			t.Errorf("%s: looksUTF8(%q, %v) = %v, want %v", test.name, test.sample, test.truncated, got, test.want)
+		}
+	}
+}
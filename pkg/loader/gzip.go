@@ -0,0 +1,47 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// GzipExtension marks a gzip-compressed file, e.g. data.csv.gz
+const GzipExtension = ".gz"
+
+// gzipMagic is the header every gzip stream starts with
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Decompress wraps r so gzip-compressed input reads as its decompressed
+// content. Input is treated as gzip when forced, e.g. by a .gz extension, or
+// when it starts with the gzip magic bytes; anything else passes through.
+func Decompress(r io.Reader, forced bool) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	// An empty input has nothing to decompress, even when named .gz
+	if len(magic) == 0 || (!forced && !bytes.Equal(magic, gzipMagic)) {
+		return buffered, nil
+	}
+
+	gz, err := gzip.NewReader(buffered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress gzip input: %w", err)
+	}
+	return gz, nil
+}
+
+// TrimGzipExtension removes a trailing .gz from path, so the extension of
+// the compressed file's content can be read, e.g. data.csv.gz -> data.csv
+func TrimGzipExtension(path string) string {
+	if strings.EqualFold(filepath.Ext(path), GzipExtension) {
+		return path[:len(path)-len(GzipExtension)]
+	}
+	return path
+}
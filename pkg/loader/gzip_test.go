@@ -0,0 +1,40 @@
+package loader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+func TestDecompress(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte("id,name\n1,a\n"))
+	gz.Close()
+
+	for _, test := range []struct {
+		name   string
+		input  []byte
+		forced bool
+		want   string
+	}{
+		{"gzip by its magic bytes", compressed.Bytes(), false, "id,name\n1,a\n"},
+		{"forced gzip", compressed.Bytes(), true, "id,name\n1,a\n"},
+		{"plain text", []byte("id,name\n"), false, "id,name\n"},
+		{"empty", nil, false, ""},
+		{"empty forced gzip", nil, true, ""},
+	} {
+		r, err := Decompress(bytes.NewReader(test.input), test.forced)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if string(got) != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
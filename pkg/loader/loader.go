@@ -2,6 +2,7 @@ package loader
 
 import (
 	"bufio"
+	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
@@ -23,6 +24,10 @@ type FileLoader struct {
 	fileInfo FileInfo
 	reader   *bufio.Reader
 	file     *os.File
+	// gzipped is true when the file has a .gz extension
+	gzipped bool
+	// compressed is true once opened when the content is being decompressed
+	compressed bool
 }
 
 // NewFileLoader creates a new file loader instance
@@ -41,8 +46,9 @@ func NewFileLoader(path string) (*FileLoader, error) {
 		return nil, fmt.Errorf("cannot load directory, must be a file")
 	}
 
+	// Compressed files report the extension of their content
 	fileName := fileInfo.Name()
-	extension := strings.ToLower(filepath.Ext(fileName))
+	extension := strings.ToLower(filepath.Ext(TrimGzipExtension(fileName)))
 
 	return &FileLoader{
 		filePath: absPath,
@@ -52,20 +58,34 @@ func NewFileLoader(path string) (*FileLoader, error) {
 			Extension: extension,
 			Size:      fileInfo.Size(),
 		},
+		gzipped: strings.EqualFold(filepath.Ext(fileName), GzipExtension),
 	}, nil
 }
 
-// Open opens the file for reading
+// Open opens the file for reading, decompressing gzip content transparently
 func (f *FileLoader) Open() error {
 	file, err := os.Open(f.filePath)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %w", err)
 	}
+
+	content, err := Decompress(file, f.gzipped)
+	if err != nil {
+		file.Close()
+		return err
+	}
 	f.file = file
-	f.reader = bufio.NewReader(file)
+	f.reader = bufio.NewReader(content)
+	_, f.compressed = content.(*gzip.Reader)
 	return nil
 }
 
+// Compressed reports whether the opened file is gzip content, which reads
+// as more bytes than its size
+func (f *FileLoader) Compressed() bool {
+	return f.compressed
+}
+
 // Close closes the file
 func (f *FileLoader) Close() error {
 	if f.file != nil {
@@ -74,6 +94,15 @@ func (f *FileLoader) Close() error {
 	return nil
 }
 
+// Read reads from the opened file, making FileLoader usable as an io.Reader
+// for streaming consumers. Open must be called first.
+func (f *FileLoader) Read(p []byte) (int, error) {
+	if f.reader == nil {
+		return 0, fmt.Errorf("file is not open")
+	}
+	return f.reader.Read(p)
+}
+
 // ReadAll reads the entire file content
 func (f *FileLoader) ReadAll() ([]byte, error) {
 	if f.file == nil {
@@ -95,7 +124,7 @@ func (f *FileLoader) ReadLines(callback func(line string) bool) error {
 		defer f.Close()
 	}
 
-	scanner := bufio.NewScanner(f.file)
+	scanner := bufio.NewScanner(f.reader)
 	for scanner.Scan() {
 		line := scanner.Text()
 		if !callback(line) {
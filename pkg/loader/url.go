@@ -0,0 +1,82 @@
+package loader
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+// URLTimeout bounds a whole URL fetch, reading the response body included
+const URLTimeout = 30 * time.Second
+
+// MaxRedirects is how many redirects a URL fetch follows before failing
+const MaxRedirects = 10
+
+// httpClient fetches URLs with the timeout and redirect limit applied
+var httpClient = &http.Client{
+	Timeout: URLTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= MaxRedirects {
+			return fmt.Errorf("stopped after %d redirects", MaxRedirects)
+		}
+		return nil
+	},
+}
+
+// IsURL reports whether source is an http or https URL rather than a path
+func IsURL(source string) bool {
+	u, err := url.Parse(source)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// URLPath returns the path part of a URL, e.g. /export/data.csv, for reading
+// the extension of what it serves
+func URLPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return path.Clean(u.Path)
+}
+
+// URLBody is the body of a fetched URL, decompressed if it was gzip content
+type URLBody struct {
+	io.Reader
+	body io.Closer
+
+	// MediaType is the response's Content-Type without parameters, e.g.
+	// application/json, or "" when the server sent none
+	MediaType string
+}
+
+// Close closes the response body
+func (b *URLBody) Close() error {
+	return b.body.Close()
+}
+
+// OpenURL fetches an http or https URL for reading. Responses outside the
+// 2xx range are reported as errors with their status.
+func OpenURL(rawURL string) (*URLBody, error) {
+	resp, err := httpClient.Get(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch %s: server responded %s", rawURL, resp.Status)
+	}
+
+	// Compressed files served as is are recognized by their magic bytes
+	content, err := Decompress(resp.Body, false)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return &URLBody{Reader: content, body: resp.Body, MediaType: mediaType}, nil
+}
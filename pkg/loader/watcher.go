@@ -0,0 +1,77 @@
+package loader
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is how long a watched file must stay quiet before a change is reported
+const DefaultDebounce = 200 * time.Millisecond
+
+// FileWatcher reports changes to a single file
+type FileWatcher struct {
+	path     string
+	debounce time.Duration
+	watcher  *fsnotify.Watcher
+}
+
+// NewFileWatcher starts watching the file at path. The parent directory is
+// watched rather than the file itself, so tools that replace the file by
+// renaming a new one over it are still noticed.
+func NewFileWatcher(path string) (*FileWatcher, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(absPath)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", path, err)
+	}
+
+	return &FileWatcher{
+		path:     absPath,
+		debounce: DefaultDebounce,
+		watcher:  watcher,
+	}, nil
+}
+
+// Wait blocks until the file changes and then stays unchanged for the
+// debounce interval, so a file that is still being written isn't read
+// half-way. It returns false once the watcher is closed.
+func (w *FileWatcher) Wait() (bool, error) {
+	var settled <-chan time.Time
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return false, nil
+			}
+			if filepath.Clean(event.Name) != w.path || event.Op == fsnotify.Chmod {
+				continue
+			}
+			// Restart the quiet period on every write
+			settled = time.After(w.debounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return false, nil
+			}
+			return true, fmt.Errorf("file watcher: %w", err)
+		case <-settled:
+			return true, nil
+		}
+	}
+}
+
+// Close stops watching the file
+func (w *FileWatcher) Close() error {
+	return w.watcher.Close()
+}
@@ -0,0 +1,156 @@
+package model
+
+import (
+	"strconv"
+)
+
+// DiffKind says how a node differs from the other side of a diff
+type DiffKind int
+
+const (
+	// DiffSame marks a node equal on both sides
+	DiffSame DiffKind = iota
+	// DiffAdded marks a node only the right tree has
+	DiffAdded
+	// DiffRemoved marks a node only the left tree has
+	DiffRemoved
+	// DiffChanged marks a value replaced by a different one, or a container
+	// holding changes
+	DiffChanged
+)
+
+// TreeDiff annotates the nodes of two trees with how they differ, by path
+type TreeDiff struct {
+	Left  map[string]DiffKind // Changed and removed nodes of the left tree
+	Right map[string]DiffKind // Changed and added nodes of the right tree
+
+	// Counts of the values added, removed and changed. A subtree added or
+	// removed as a whole counts once, and containers holding changes don't count.
+	Added, Removed, Changed int
+}
+
+// DiffTrees compares two trees. Object entries are matched by key, keeping
+// the first of any repeated key, and array elements by position. Numbers
+// are equal when they have the same value, however they were written.
+// Paths missing from the maps are the same on both sides.
+func DiffTrees(left, right *JSONNode) *TreeDiff {
+	d := &TreeDiff{Left: make(map[string]DiffKind), Right: make(map[string]DiffKind)}
+	d.compare(left.Path, left.RawValue(), right.RawValue())
+	return d
+}
+
+// compare records the differences between two values at path and reports
+// whether there were any
+func (d *TreeDiff) compare(path string, left, right interface{}) bool {
+	leftType, rightType := newNode("", left, nil).Type, newNode("", right, nil).Type
+	if leftType != rightType {
+		// A value of another type replaces the old one with all it held
+		markAll(d.Left, path, left, DiffRemoved)
+		markAll(d.Right, path, right, DiffAdded)
+		d.Left[path], d.Right[path] = DiffChanged, DiffChanged
+		d.Changed++
+		return true
+	}
+
+	var differs bool
+	switch leftType {
+	case NodeObject:
+		differs = d.compareObjects(path, left, right)
+	case NodeArray:
+		differs = d.compareArrays(path, left.([]interface{}), right.([]interface{}))
+	default:
+		differs = !scalarsEqual(left, right)
+		if differs {
+			d.Changed++
+		}
+	}
+	if differs {
+		d.Left[path], d.Right[path] = DiffChanged, DiffChanged
+	}
+	return differs
+}
+
+// compareObjects compares the entries of two objects by key
+func (d *TreeDiff) compareObjects(path string, left, right interface{}) bool {
+	leftEntries, leftKeys := firstEntries(left)
+	rightEntries, rightKeys := firstEntries(right)
+
+	differs := false
+	for _, key := range leftKeys {
+		childPath := joinPath(path, false, key, 0)
+		if rightValue, ok := rightEntries[key]; ok {
+			differs = d.compare(childPath, leftEntries[key], rightValue) || differs
+		} else {
+			markAll(d.Left, childPath, leftEntries[key], DiffRemoved)
+			d.Removed++
+			differs = true
+		}
+	}
+	for _, key := range rightKeys {
+		if _, ok := leftEntries[key]; !ok {
+			markAll(d.Right, joinPath(path, false, key, 0), rightEntries[key], DiffAdded)
+			d.Added++
+			differs = true
+		}
+	}
+	return differs
+}
+
+// compareArrays compares two arrays element by element
+func (d *TreeDiff) compareArrays(path string, left, right []interface{}) bool {
+	differs := len(left) != len(right)
+	for i := 0; i < max(len(left), len(right)); i++ {
+		childPath := joinPath(path, true, "", i)
+		switch {
+		case i >= len(right):
+			markAll(d.Left, childPath, left[i], DiffRemoved)
+			d.Removed++
+		case i >= len(left):
+			markAll(d.Right, childPath, right[i], DiffAdded)
+			d.Added++
+		default:
+			differs = d.compare(childPath, left[i], right[i]) || differs
+		}
+	}
+	return differs
+}
+
+// firstEntries indexes an object's values by key, keeping the first value of
+// a repeated key, and lists the keys in order
+func firstEntries(value interface{}) (map[string]interface{}, []string) {
+	entries := make(map[string]interface{})
+	var keys []string
+	forEachChild(value, func(key string, child interface{}) {
+		if _, seen := entries[key]; !seen {
+			entries[key] = child
+			keys = append(keys, key)
+		}
+	})
+	return entries, keys
+}
+
+// markAll gives a value and everything below it the same kind
+func markAll(kinds map[string]DiffKind, path string, value interface{}, kind DiffKind) {
+	kinds[path] = kind
+	_, isArray := value.([]interface{})
+	index := 0
+	forEachChild(value, func(key string, child interface{}) {
+		markAll(kinds, joinPath(path, isArray, key, index), child, kind)
+		index++
+	})
+}
+
+// scalarsEqual reports whether two scalars of the same type are equal,
+// comparing numbers by value so 1.0 equals 1
+func scalarsEqual(left, right interface{}) bool {
+	leftText, rightText := String(left), String(right)
+	if leftText == rightText {
+		return true
+	}
+	if newNode("", left, nil).Type != NodeNumber {
+		return false
+	}
+	leftNumber, leftErr := strconv.ParseFloat(leftText, 64)
+	rightNumber, rightErr := strconv.ParseFloat(rightText, 64)
+	return leftErr == nil && rightErr == nil && leftNumber == rightNumber
+}
@@ -1,7 +1,12 @@
 package model
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
 )
 
 // NodeType represents the type of a JSON node
@@ -22,6 +27,40 @@ const (
 	NodeNull
 )
 
+// ObjectEntry is a single key/value pair of a JSON object
+type ObjectEntry struct {
+	Key   string
+	Value interface{}
+}
+
+// OrderedObject is a JSON object that keeps its keys in their original textual order
+type OrderedObject []ObjectEntry
+
+// MarshalJSON encodes the object with its keys in their original order
+func (o OrderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+	for i, entry := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := enc.Encode(entry.Key); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := enc.Encode(entry.Value); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+
+	// Encode appends newlines after each value; they are insignificant whitespace
+	return buf.Bytes(), nil
+}
+
 // JSONNode represents a node in the JSON tree
 type JSONNode struct {
 	Key      string
@@ -31,48 +70,66 @@ type JSONNode struct {
 	Parent   *JSONNode
 	Expanded bool
 	Path     string
+	// DuplicateKey is true when an earlier sibling in the same object has the
+	// same key. The node then has that sibling's Path too, and lookups by path
+	// resolve to the first occurrence.
+	DuplicateKey bool
+	// lazy is true while the children of a container haven't been built from Value
+	lazy bool
+	// index is the node's position among its parent's children, or -1 for the root
+	index int
 }
 
-// NewJSONNode creates a new JSON node
+// NewJSONNode creates a new JSON node, eagerly building the whole subtree
 func NewJSONNode(key string, value interface{}, parent *JSONNode) *JSONNode {
+	node := newNode(key, value, parent)
+	node.Expanded = true
+
+	// Create children for complex types
+	forEachChild(value, func(childKey string, childValue interface{}) {
+		child := NewJSONNode(childKey, childValue, node)
+		node.Children = append(node.Children, child)
+	})
+	markDuplicateKeys(node)
+
+	return node
+}
+
+// NewLazyJSONNode creates a collapsed JSON node whose children are only built
+// from the stored value when the node is first expanded. This keeps very large
+// documents cheap to open, since untouched subtrees never become nodes.
+func NewLazyJSONNode(key string, value interface{}, parent *JSONNode) *JSONNode {
+	node := newNode(key, value, parent)
+	node.lazy = valueLen(value) > 0
+	node.Expanded = !node.lazy
+	return node
+}
+
+// newNode creates a node with its path and type set but no children
+func newNode(key string, value interface{}, parent *JSONNode) *JSONNode {
 	node := &JSONNode{
 		Key:      key,
 		Value:    value,
 		Parent:   parent,
 		Children: []*JSONNode{},
-		Expanded: true,
+		index:    -1,
 	}
 
-	// Set path
+	// Set path; children are appended in order, so the next slot is this node's index
 	if parent != nil {
-		if parent.Path == "" {
-			node.Path = key
-		} else if key == "" { // Array element
-			node.Path = parent.Path
-		} else {
-			node.Path = parent.Path + "." + key
-		}
-	} else {
-		node.Path = ""
+		node.index = len(parent.Children)
+		node.Path = childPath(parent, key, node.index)
 	}
 
-	// Determine node type and create children for complex types
-	switch v := value.(type) {
-	case map[string]interface{}:
+	// Determine node type
+	switch value.(type) {
+	case OrderedObject, map[string]interface{}:
 		node.Type = NodeObject
-		for k, val := range v {
-			child := NewJSONNode(k, val, node)
-			node.Children = append(node.Children, child)
-		}
 	case []interface{}:
 		node.Type = NodeArray
-		for _, val := range v {
-			child := NewJSONNode("", val, node)
-			node.Children = append(node.Children, child)
-		}
 	case string:
 		node.Type = NodeString
-	case float64, int, int64:
+	case float64, int, int64, json.Number:
 		node.Type = NodeNumber
 	case bool:
 		node.Type = NodeBoolean
@@ -83,21 +140,212 @@ func NewJSONNode(key string, value interface{}, parent *JSONNode) *JSONNode {
 	return node
 }
 
+// forEachChild calls fn for each key/value held by a container value, in display order
+func forEachChild(value interface{}, fn func(key string, value interface{})) {
+	switch v := value.(type) {
+	case OrderedObject:
+		for _, entry := range v {
+			fn(entry.Key, entry.Value)
+		}
+	case map[string]interface{}:
+		// Plain maps carry no key order, so sort keys to keep trees stable
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fn(k, v[k])
+		}
+	case []interface{}:
+		for _, val := range v {
+			fn("", val)
+		}
+	}
+}
+
+// valueLen returns the number of children a container value holds
+func valueLen(value interface{}) int {
+	switch v := value.(type) {
+	case OrderedObject:
+		return len(v)
+	case map[string]interface{}:
+		return len(v)
+	case []interface{}:
+		return len(v)
+	default:
+		return 0
+	}
+}
+
+// LoadChildren builds the children of a lazy node from its stored value.
+// Children are themselves lazy. It does nothing for nodes already built.
+func (n *JSONNode) LoadChildren() {
+	if !n.lazy {
+		return
+	}
+	n.lazy = false
+
+	forEachChild(n.Value, func(childKey string, childValue interface{}) {
+		child := NewLazyJSONNode(childKey, childValue, n)
+		n.Children = append(n.Children, child)
+	})
+	markDuplicateKeys(n)
+}
+
+// markDuplicateKeys flags the children of an object whose key an earlier
+// child already has. Paths can't tell such children apart, as jq-style
+// paths name a key rather than an occurrence.
+func markDuplicateKeys(n *JSONNode) {
+	if n.Type != NodeObject {
+		return
+	}
+	seen := make(map[string]struct{}, len(n.Children))
+	for _, child := range n.Children {
+		_, child.DuplicateKey = seen[child.Key]
+		seen[child.Key] = struct{}{}
+	}
+}
+
+// RawValue reconstructs the value the node represents, with object keys in
+// their original order. Subtrees that were never built are returned as stored.
+func (n *JSONNode) RawValue() interface{} {
+	if n.lazy {
+		return n.Value
+	}
+
+	switch n.Type {
+	case NodeObject:
+		obj := make(OrderedObject, 0, len(n.Children))
+		for _, child := range n.Children {
+			obj = append(obj, ObjectEntry{Key: child.Key, Value: child.RawValue()})
+		}
+		return obj
+	case NodeArray:
+		arr := make([]interface{}, 0, len(n.Children))
+		for _, child := range n.Children {
+			arr = append(arr, child.RawValue())
+		}
+		return arr
+	default:
+		return n.Value
+	}
+}
+
+// Marshal encodes the node's value as JSON, indenting nested levels by
+// indent or producing compact output when indent is empty
+func (n *JSONNode) Marshal(indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(n.RawValue()); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// ToJSON encodes the node and its descendants as JSON indented by two spaces
+func (n *JSONNode) ToJSON() ([]byte, error) {
+	return n.Marshal("  ")
+}
+
+// childPath builds the jq-style path of a child at the given index, e.g. .users[2].name.
+// Keys that aren't plain identifiers are bracket-quoted, e.g. .config["weird.key"]
+func childPath(parent *JSONNode, key string, index int) string {
+	return joinPath(parent.Path, parent.Type == NodeArray, key, index)
+}
+
+// joinPath appends a child's segment to its parent's path: the index for an
+// element of an array, or the key for an entry of an object
+func joinPath(parentPath string, inArray bool, key string, index int) string {
+	var segment string
+	switch {
+	case inArray:
+		segment = fmt.Sprintf("[%d]", index)
+	case isIdentifier(key):
+		segment = "." + key
+	default:
+		segment = "[" + strconv.Quote(key) + "]"
+	}
+
+	// Bracketed children of the root keep a leading dot, e.g. .[0]
+	if parentPath == "" && segment[0] == '[' {
+		return "." + segment
+	}
+	return parentPath + segment
+}
+
+// isIdentifier reports whether a key can be written as .key in a path
+func isIdentifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		isLetter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		isDigit := r >= '0' && r <= '9'
+		if !isLetter && (i == 0 || !isDigit) {
+			return false
+		}
+	}
+	return true
+}
+
+// DisplayPath returns the node's path, using "." for the root
+func (n *JSONNode) DisplayPath() string {
+	if n.Path == "" {
+		return "."
+	}
+	return n.Path
+}
+
 // Toggle expands or collapses a node
 func (n *JSONNode) Toggle() {
 	if n.Type == NodeObject || n.Type == NodeArray {
 		n.Expanded = !n.Expanded
+		if n.Expanded {
+			n.LoadChildren()
+		}
+	}
+}
+
+// ExpandToDepth expands the nodes fewer than depth levels below n, n itself
+// being level 0, and collapses the deeper ones. Lazy children are only built
+// where a node is expanded.
+func (n *JSONNode) ExpandToDepth(depth int) {
+	if !n.HasChildren() {
+		return
+	}
+	n.Expanded = depth > 0
+	if n.Expanded {
+		n.LoadChildren()
+	}
+	for _, child := range n.Children {
+		child.ExpandToDepth(depth - 1)
 	}
 }
 
+// ChildCount returns the number of children, without building lazy children
+func (n *JSONNode) ChildCount() int {
+	if n.lazy {
+		return valueLen(n.Value)
+	}
+	return len(n.Children)
+}
+
+// Index returns the node's position among its parent's children, or -1 for the root
+func (n *JSONNode) Index() int {
+	return n.index
+}
+
 // IsLeaf returns true if the node is a leaf node (has no children)
 func (n *JSONNode) IsLeaf() bool {
-	return len(n.Children) == 0
+	return !n.HasChildren()
 }
 
-// HasChildren returns true if the node has children
+// HasChildren returns true if the node has children, built or not
 func (n *JSONNode) HasChildren() bool {
-	return len(n.Children) > 0
+	return n.ChildCount() > 0
 }
 
 // TypeString returns a string representation of the node type
@@ -166,6 +414,8 @@ func InterfaceToString(v interface{}) string {
 		return IntToString(val)
 	case int64:
 		return Int64ToString(val)
+	case json.Number:
+		return val.String()
 	case bool:
 		if val {
 			return "true"
@@ -178,9 +428,9 @@ func InterfaceToString(v interface{}) string {
 
 // Helper functions for string conversion
 func FloatToString(f float64) string {
-	// Check if it's an integer value
-	if f == float64(int(f)) {
-		return IntToString(int(f))
+	// Write whole numbers out in full; converting to int would overflow past 2^63
+	if f == math.Trunc(f) && !math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'f', 0, 64)
 	}
 	return ToString(f)
 }
@@ -220,6 +470,8 @@ func String(v interface{}) string {
 		return fmt.Sprintf("%d", val)
 	case float64:
 		return fmt.Sprintf("%g", val)
+	case json.Number:
+		return val.String()
 	case bool:
 		if val {
 			return "true"
@@ -0,0 +1,145 @@
+package model
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// NumberFormat controls how numbers are displayed. The zero value shows each
+// number in full with as many decimals as it needs, using an exponent only
+// for floats too large or small to write out, below 1e-21 or from 1e21 up.
+type NumberFormat struct {
+	Thousands  bool // Group the whole part in threes with commas, e.g. 1,234,567
+	Fixed      bool // Round or pad every number to Decimals digits after the point
+	Decimals   int
+	Scientific bool // Write numbers with an exponent, e.g. 1.5e+06
+}
+
+// IsZero reports whether the format is the default, under which numbers
+// read from text are shown exactly as written
+func (f NumberFormat) IsZero() bool {
+	return f == NumberFormat{}
+}
+
+// Format formats a numeric value: a float64, int, int64 or json.Number.
+// Other values are converted with String.
+func (f NumberFormat) Format(value interface{}) string {
+	switch n := value.(type) {
+	case float64:
+		return f.formatFloat(n)
+	case int:
+		return f.formatInt(int64(n))
+	case int64:
+		return f.formatInt(n)
+	case json.Number:
+		if f.IsZero() {
+			return n.String()
+		}
+		return f.FormatString(n.String())
+	default:
+		return String(value)
+	}
+}
+
+// FormatString reformats text holding a number, such as a CSV cell, and
+// returns any other text unchanged. Under the default format numbers are
+// left as written too.
+func (f NumberFormat) FormatString(s string) string {
+	if f.IsZero() {
+		return s
+	}
+	trimmed := strings.TrimSpace(s)
+	if isInteger(trimmed) {
+		return f.formatDigits(trimmed)
+	}
+	if n, err := strconv.ParseFloat(trimmed, 64); err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+		return f.formatFloat(n)
+	}
+	return s
+}
+
+// formatInt formats an integer without going through float64, so integers
+// beyond 2^53 keep every digit
+func (f NumberFormat) formatInt(n int64) string {
+	return f.formatDigits(strconv.FormatInt(n, 10))
+}
+
+// formatDigits formats an integer written in decimal digits, of any size
+func (f NumberFormat) formatDigits(s string) string {
+	if f.Scientific {
+		n, _ := strconv.ParseFloat(s, 64)
+		return f.formatFloat(n)
+	}
+	if f.Fixed && f.Decimals > 0 {
+		s += "." + strings.Repeat("0", f.Decimals)
+	}
+	return f.group(s)
+}
+
+// Floats from plainMin up to plainMax are written without an exponent unless
+// the format asks for one; JavaScript writes JSON numbers out up to 1e21 too
+const (
+	plainMin = 1e-21
+	plainMax = 1e21
+)
+
+// formatFloat formats a float, writing out whole numbers below plainMax in full
+func (f NumberFormat) formatFloat(n float64) string {
+	if math.IsInf(n, 0) || math.IsNaN(n) {
+		return strconv.FormatFloat(n, 'g', -1, 64)
+	}
+	if abs := math.Abs(n); !f.Fixed && !f.Scientific && n != 0 && (abs < plainMin || abs >= plainMax) {
+		return strconv.FormatFloat(n, 'g', -1, 64)
+	}
+
+	precision := -1
+	if f.Fixed {
+		precision = f.Decimals
+	}
+	if f.Scientific {
+		return strconv.FormatFloat(n, 'e', precision, 64)
+	}
+	return f.group(strconv.FormatFloat(n, 'f', precision, 64))
+}
+
+// isInteger reports whether s is an optionally negative run of decimal digits
+func isInteger(s string) bool {
+	digits := strings.TrimPrefix(s, "-")
+	if digits == "" {
+		return false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// group inserts thousands separators into the whole part of a plain decimal
+// number when the format asks for them
+func (f NumberFormat) group(s string) string {
+	if !f.Thousands {
+		return s
+	}
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, fraction := s, ""
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		whole, fraction = s[:dot], s[dot:]
+	}
+
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return sign + b.String() + fraction
+}
@@ -0,0 +1,26 @@
+package model
+
+import "testing"
+
+func TestFormatFloatExponents(t *testing.T) {
+	for _, test := range []struct {
+		format NumberFormat
+		value  float64
+		want   string
+	}{
+		{NumberFormat{}, 1e20, "100000000000000000000"},
+		{NumberFormat{}, 1e21, "1e+21"},
+		{NumberFormat{}, 1e300, "1e+300"},
+		{NumberFormat{}, -1e-300, "-1e-300"},
+		{NumberFormat{}, 0.000001, "0.000001"},
+		{NumberFormat{}, 0, "0"},
+		{NumberFormat{Thousands: true}, 1e300, "1e+300"},
+		{NumberFormat{Thousands: true}, 1234567.5, "1,234,567.5"},
+		{NumberFormat{Fixed: true, Decimals: 2}, 1e-300, "0.00"},
+		{NumberFormat{Scientific: true}, 1500000, "1.5e+06"},
+	} {
+		if got := test.format.Format(test.value); got != test.want {
+			t.Errorf("%+v formats %v as %q, want %q", test.format, test.value, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"strings"
+)
+
+// OptionalKeyMark ends the schema keys that only some of the merged objects have
+const OptionalKeyMark = "?"
+
+// schema accumulates the shape of every value seen at one place in a document
+type schema struct {
+	primitives []string // Names of the scalar types seen, in the order first seen
+	object     *objectSchema
+	array      *schema // Merged schema of all array elements, when an array was seen
+}
+
+// objectSchema merges the objects seen at one place, keeping their keys in
+// the order first seen and counting how many of the objects had each
+type objectSchema struct {
+	keys    []string
+	fields  map[string]*schema
+	present map[string]int
+	objects int
+}
+
+// InferSchema builds a tree describing the structure of a document rather
+// than its values. Each scalar becomes the name of its type, e.g. "string",
+// or the names of all the types seen there joined by " | ". The elements of
+// an array are merged into one element schema; objects merged this way mark
+// the keys missing from some of them with OptionalKeyMark. Where objects or
+// arrays are mixed with scalars the scalar types follow the key, as in
+// "address | null", or in arrays make up a second element.
+func InferSchema(root *JSONNode) *JSONNode {
+	s := &schema{}
+	s.add(root.RawValue())
+	return NewJSONNode("root", s.value(), nil)
+}
+
+// add merges a value into the schema
+func (s *schema) add(value interface{}) {
+	switch v := value.(type) {
+	case OrderedObject, map[string]interface{}:
+		if s.object == nil {
+			s.object = &objectSchema{fields: make(map[string]*schema), present: make(map[string]int)}
+		}
+		s.object.add(v)
+	case []interface{}:
+		if s.array == nil {
+			s.array = &schema{}
+		}
+		for _, element := range v {
+			s.array.add(element)
+		}
+	default:
+		name := newNode("", value, nil).TypeString()
+		for _, seen := range s.primitives {
+			if seen == name {
+				return
+			}
+		}
+		s.primitives = append(s.primitives, name)
+	}
+}
+
+// add merges the entries of an object into the object schema
+func (o *objectSchema) add(value interface{}) {
+	o.objects++
+	forEachChild(value, func(key string, child interface{}) {
+		field, ok := o.fields[key]
+		if !ok {
+			field = &schema{}
+			o.fields[key] = field
+			o.keys = append(o.keys, key)
+		}
+		// Repeated keys in one object still count it only once
+		if o.present[key] < o.objects {
+			o.present[key]++
+		}
+		field.add(child)
+	})
+}
+
+// value renders the schema as a document value: an object or array of
+// schemas, or a string naming the scalar types. A place holding both
+// objects and arrays shows as an object.
+func (s *schema) value() interface{} {
+	switch {
+	case s.object != nil:
+		obj := make(OrderedObject, 0, len(s.object.keys))
+		for _, key := range s.object.keys {
+			field := s.object.fields[key]
+			label := key
+			if s.object.present[key] < s.object.objects {
+				label += OptionalKeyMark
+			}
+			if field.isMixed() {
+				label += " | " + strings.Join(field.primitives, " | ")
+			}
+			obj = append(obj, ObjectEntry{Key: label, Value: field.value()})
+		}
+		return obj
+	case s.array != nil:
+		// Elements have no key to note scalars on, so mixed elements are
+		// listed as two alternatives instead
+		elements := []interface{}{}
+		if s.array.object != nil || s.array.array != nil {
+			elements = append(elements, s.array.value())
+		}
+		if len(s.array.primitives) > 0 {
+			elements = append(elements, strings.Join(s.array.primitives, " | "))
+		}
+		return elements
+	default:
+		return strings.Join(s.primitives, " | ")
+	}
+}
+
+// isMixed reports whether scalars were seen alongside objects or arrays
+func (s *schema) isMixed() bool {
+	return (s.object != nil || s.array != nil) && len(s.primitives) > 0
+}
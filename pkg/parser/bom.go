@@ -0,0 +1,24 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// utf8BOM is the byte order mark some tools, notably Excel, write at the start of UTF-8 files
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// stripBOM removes a leading UTF-8 byte order mark
+func stripBOM(data []byte) []byte {
+	return bytes.TrimPrefix(data, utf8BOM)
+}
+
+// stripBOMReader returns a reader that skips a leading UTF-8 byte order mark
+func stripBOMReader(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if prefix, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		br.Discard(len(utf8BOM))
+	}
+	return br
+}
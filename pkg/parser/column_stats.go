@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ColumnStats summarizes the cells of one column
+type ColumnStats struct {
+	Type     ColumnType
+	Count    int // Cells looked at, nulls included
+	Nulls    int // Empty cells, and cells of a numeric column that aren't numbers
+	Distinct int // Distinct values among the cells that aren't null
+
+	// Computed over the numbers of a numeric column, and zero otherwise
+	Min    float64
+	Max    float64
+	Sum    float64
+	Mean   float64
+	Median float64
+}
+
+// ColumnStatsFor computes statistics for a column over the rows at the
+// given indices, or over every row when rows is nil
+func (c *CSVData) ColumnStatsFor(colIndex int, rows []int) ColumnStats {
+	stats := ColumnStats{Type: c.ColumnTypeOf(colIndex)}
+	numeric := stats.Type.IsNumeric()
+
+	seen := make(map[string]struct{})
+	var numbers []float64
+	visit := func(row []string) {
+		stats.Count++
+		cell := strings.TrimSpace(cellAt(row, colIndex))
+		if cell == "" {
+			stats.Nulls++
+			return
+		}
+
+		if numeric {
+			number, err := strconv.ParseFloat(cell, 64)
+			if err != nil {
+				stats.Nulls++
+				return
+			}
+			numbers = append(numbers, number)
+		}
+		seen[cell] = struct{}{}
+	}
+
+	c.eachRow(rows, visit)
+	stats.Distinct = len(seen)
+
+	if len(numbers) == 0 {
+		return stats
+	}
+
+	sort.Float64s(numbers)
+	stats.Min = numbers[0]
+	stats.Max = numbers[len(numbers)-1]
+	for _, number := range numbers {
+		stats.Sum += number
+	}
+	stats.Mean = stats.Sum / float64(len(numbers))
+
+	middle := len(numbers) / 2
+	if len(numbers)%2 == 0 {
+		stats.Median = (numbers[middle-1] + numbers[middle]) / 2
+	} else {
+		stats.Median = numbers[middle]
+	}
+	return stats
+}
+
+// ValueCount is a distinct value of a column and the number of cells holding it
+type ValueCount struct {
+	Value string
+	Count int
+}
+
+// DistinctCounts counts how many rows hold each distinct value of a column
+func (c *CSVData) DistinctCounts(colIndex int) map[string]int {
+	return c.DistinctCountsFor(colIndex, nil)
+}
+
+// DistinctCountsFor counts each distinct value of a column over the rows at
+// the given indices, or over every row when rows is nil
+func (c *CSVData) DistinctCountsFor(colIndex int, rows []int) map[string]int {
+	counts := make(map[string]int)
+	c.eachRow(rows, func(row []string) {
+		counts[cellAt(row, colIndex)]++
+	})
+	return counts
+}
+
+// SortByFrequency lists counts from the most to the least common value,
+// with ties in value order
+func SortByFrequency(counts map[string]int) []ValueCount {
+	sorted := make([]ValueCount, 0, len(counts))
+	for value, count := range counts {
+		sorted = append(sorted, ValueCount{Value: value, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Value < sorted[j].Value
+	})
+	return sorted
+}
+
+// eachRow calls fn with the rows at the given indices, or with every row
+// when rows is nil
+func (c *CSVData) eachRow(rows []int, fn func(row []string)) {
+	if rows == nil {
+		for _, row := range c.Rows {
+			fn(row)
+		}
+		return
+	}
+	for _, rowIdx := range rows {
+		fn(c.Rows[rowIdx])
+	}
+}
+
+// FindDuplicates returns the indices of the rows whose key some other row
+// shares, in row order. The key is the cell at colIndex, or the whole row
+// when colIndex is negative.
+func (c *CSVData) FindDuplicates(colIndex int) []int {
+	counts := make(map[string]int)
+	for _, row := range c.Rows {
+		counts[rowKey(row, colIndex)]++
+	}
+
+	var duplicates []int
+	for i, row := range c.Rows {
+		if counts[rowKey(row, colIndex)] > 1 {
+			duplicates = append(duplicates, i)
+		}
+	}
+	return duplicates
+}
+
+// FirstOccurrences returns the indices of the rows whose key no earlier row
+// has, keyed like FindDuplicates
+func (c *CSVData) FirstOccurrences(colIndex int) []int {
+	seen := make(map[string]struct{})
+	var firsts []int
+	for i, row := range c.Rows {
+		key := rowKey(row, colIndex)
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			firsts = append(firsts, i)
+		}
+	}
+	return firsts
+}
+
+// rowKey returns the cell at colIndex, or the whole row joined by a unit
+// separator when colIndex is negative
+func rowKey(row []string, colIndex int) string {
+	if colIndex < 0 {
+		return strings.Join(row, "\x1f")
+	}
+	return cellAt(row, colIndex)
+}
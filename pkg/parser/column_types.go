@@ -0,0 +1,183 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ColumnType represents the inferred type of a CSV column
+type ColumnType int
+
+// Column type constants
+const (
+	// ColumnString is the fallback when no narrower type fits every cell
+	ColumnString ColumnType = iota
+	// ColumnInteger means every non-empty cell is a whole number
+	ColumnInteger
+	// ColumnFloat means every non-empty cell is a number
+	ColumnFloat
+	// ColumnBoolean means every non-empty cell is true/false
+	ColumnBoolean
+	// ColumnDate means every non-empty cell is a date or timestamp
+	ColumnDate
+)
+
+// dateLayouts lists the date formats recognized during type inference
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006/01/02",
+	"01/02/2006",
+}
+
+// String returns the string representation of the column type
+func (t ColumnType) String() string {
+	switch t {
+	case ColumnInteger:
+		return "Integer"
+	case ColumnFloat:
+		return "Float"
+	case ColumnBoolean:
+		return "Boolean"
+	case ColumnDate:
+		return "Date"
+	default:
+		return "String"
+	}
+}
+
+// IsNumeric reports whether the column holds numbers
+func (t ColumnType) IsNumeric() bool {
+	return t == ColumnInteger || t == ColumnFloat
+}
+
+// InferColumnTypes classifies each column by checking whether every non-empty
+// cell parses as the type. The result is cached after the first call.
+func (c *CSVData) InferColumnTypes() []ColumnType {
+	if c.columnTypes != nil {
+		return c.columnTypes
+	}
+
+	c.columnTypes = make([]ColumnType, len(c.Headers))
+	for col := range c.Headers {
+		c.columnTypes[col] = c.inferColumnType(col)
+	}
+
+	return c.columnTypes
+}
+
+// ColumnTypeOf returns the inferred type of a single column
+func (c *CSVData) ColumnTypeOf(colIndex int) ColumnType {
+	types := c.InferColumnTypes()
+	if colIndex >= 0 && colIndex < len(types) {
+		return types[colIndex]
+	}
+	return ColumnString
+}
+
+// inferColumnType narrows down the type of a single column
+func (c *CSVData) inferColumnType(col int) ColumnType {
+	isInteger, isFloat, isBoolean, isDate := true, true, true, true
+	seen := false
+
+	for _, row := range c.Rows {
+		if col >= len(row) {
+			continue
+		}
+		cell := strings.TrimSpace(row[col])
+		if cell == "" {
+			continue
+		}
+		seen = true
+
+		if isInteger {
+			_, err := strconv.ParseInt(cell, 10, 64)
+			isInteger = err == nil
+		}
+		if isFloat {
+			_, err := strconv.ParseFloat(cell, 64)
+			isFloat = err == nil
+		}
+		if isBoolean {
+			_, err := strconv.ParseBool(cell)
+			isBoolean = err == nil
+		}
+		if isDate {
+			_, ok := parseDate(cell)
+			isDate = ok
+		}
+
+		if !isInteger && !isFloat && !isBoolean && !isDate {
+			return ColumnString
+		}
+	}
+
+	switch {
+	case !seen:
+		return ColumnString
+	case isInteger:
+		return ColumnInteger
+	case isFloat:
+		return ColumnFloat
+	case isBoolean:
+		return ColumnBoolean
+	case isDate:
+		return ColumnDate
+	default:
+		return ColumnString
+	}
+}
+
+// parseDate tries each known date layout
+func parseDate(value string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// compareCells orders two cells according to the column type.
+// Cells that fail to parse sort before those that do.
+func compareCells(a, b string, columnType ColumnType) int {
+	switch columnType {
+	case ColumnInteger, ColumnFloat:
+		fa, errA := strconv.ParseFloat(strings.TrimSpace(a), 64)
+		fb, errB := strconv.ParseFloat(strings.TrimSpace(b), 64)
+		if errA == nil && errB == nil {
+			switch {
+			case fa < fb:
+				return -1
+			case fa > fb:
+				return 1
+			}
+			return 0
+		}
+		return compareParsed(errA == nil, errB == nil, a, b)
+
+	case ColumnDate:
+		ta, okA := parseDate(strings.TrimSpace(a))
+		tb, okB := parseDate(strings.TrimSpace(b))
+		if okA && okB {
+			return ta.Compare(tb)
+		}
+		return compareParsed(okA, okB, a, b)
+
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// compareParsed orders cells when at least one failed to parse
+func compareParsed(okA, okB bool, a, b string) int {
+	switch {
+	case okA && !okB:
+		return 1
+	case !okA && okB:
+		return -1
+	}
+	return strings.Compare(a, b)
+}
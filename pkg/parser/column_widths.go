@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"runtime"
+	"sync"
+)
+
+// parallelWidthRows is how many rows each goroutine measuring column widths
+// handles at least; smaller tables are measured on the calling goroutine, as
+// starting goroutines would cost more than it saves
+const parallelWidthRows = 20000
+
+// MaxCellWidths returns the widest cell of each of the first cols columns
+// across rows, as measured by measure, or 0 for a column without cells.
+// Large tables are split into chunks measured in parallel and then combined,
+// so measure must be safe to call from several goroutines at once.
+func MaxCellWidths(rows [][]string, cols int, measure func(col int, cell string) int) []int {
+	workers := min(runtime.GOMAXPROCS(0), len(rows)/parallelWidthRows)
+	if workers <= 1 {
+		return maxCellWidths(rows, cols, measure)
+	}
+
+	chunkSize := (len(rows) + workers - 1) / workers
+	partial := make([][]int, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		chunk := rows[w*chunkSize : min((w+1)*chunkSize, len(rows))]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			partial[w] = maxCellWidths(chunk, cols, measure)
+		}()
+	}
+	wg.Wait()
+
+	widths := partial[0]
+	for _, chunkWidths := range partial[1:] {
+		for i, width := range chunkWidths {
+			widths[i] = max(widths[i], width)
+		}
+	}
+	return widths
+}
+
+// maxCellWidths measures the cells of rows one after another
+func maxCellWidths(rows [][]string, cols int, measure func(col int, cell string) int) []int {
+	widths := make([]int, cols)
+	for _, row := range rows {
+		for i, cell := range row[:min(len(row), cols)] {
+			if width := measure(i, cell); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+	return widths
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"runtime"
+	"slices"
+	"strconv"
+	"testing"
+	"unicode/utf8"
+)
+
+// raggedRows generates n rows of up to cols cells, some short and some
+// with extra cells, whose widths vary from row to row
+func raggedRows(n, cols int) [][]string {
+	rows := make([][]string, n)
+	for i := range rows {
+		row := make([]string, (i*7)%(cols+2))
+		for j := range row {
+			row[j] = strconv.Itoa(i * (j + 1) % 100003)
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// runeWidth measures a cell by its runes, ignoring the column
+func runeWidth(_ int, cell string) int {
+	return utf8.RuneCountInString(cell)
+}
+
+func TestMaxCellWidthsMatchesSequential(t *testing.T) {
+	// Run several workers even on a machine with one CPU, so the rows are
+	// really split into chunks
+	const workers = 4
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(workers))
+
+	const cols = 5
+	for _, n := range []int{
+		0,                                 // No rows, so no chunks
+		parallelWidthRows,                 // One chunk, measured without goroutines
+		(workers+1)*parallelWidthRows + 7, // Every worker, the last with a short chunk
+	} {
+		rows := raggedRows(n, cols)
+		want := maxCellWidths(rows, cols, runeWidth)
+		if got := MaxCellWidths(rows, cols, runeWidth); !slices.Equal(got, want) {
+			t.Errorf("%d rows: got widths %v, measured one after another %v", n, got, want)
+		}
+	}
+}
+
+func BenchmarkMaxCellWidths(b *testing.B) {
+	rows := raggedRows(1000000, 8)
+	b.Run("sequential", func(b *testing.B) {
+		for range b.N {
+			maxCellWidths(rows, 8, runeWidth)
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for range b.N {
+			MaxCellWidths(rows, 8, runeWidth)
+		}
+	})
+}
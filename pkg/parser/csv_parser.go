@@ -1,10 +1,13 @@
 package parser
 
 import (
+	"bytes"
 	"encoding/csv"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+	"unicode"
 )
 
 // CSVData represents parsed CSV data
@@ -18,6 +21,10 @@ type CSVData struct {
 	// Track sorting order
 	SortColumn int
 	SortAsc    bool
+	// Cached result of InferColumnTypes
+	columnTypes []ColumnType
+	// Original 0-based position of each row, permuted by sorting; nil until the first sort
+	rowOrder []int
 }
 
 // NewCSVData creates a new empty CSVData structure
@@ -31,11 +38,21 @@ func NewCSVData() *CSVData {
 	}
 }
 
+// DefaultComment starts the lines a CSV parser skips unless told otherwise
+const DefaultComment = '#'
+
 // CSVParser parses CSV data
 type CSVParser struct {
 	// Configuration options (delimiter etc.)
-	Comma                rune
-	Comment              rune
+	Comma     rune
+	Comment   rune       // Lines starting with it are skipped; 0 keeps every line
+	Header    HeaderMode // Whether the first row names the columns
+	TrimSpace bool       // Strip whitespace around every field, e.g. "a , b" -> "a", "b"
+
+	// UseFirstLineAsHeader is false to read the first row as data when
+	// Header is HeaderAlways. NewCSVParser sets it to true.
+	//
+	// Deprecated: Set Header to HeaderNever instead.
 	UseFirstLineAsHeader bool
 }
 
@@ -43,16 +60,49 @@ type CSVParser struct {
 func NewCSVParser() *CSVParser {
 	return &CSVParser{
 		Comma:                ',',
-		Comment:              '#',
+		Comment:              DefaultComment,
+		Header:               HeaderAlways,
 		UseFirstLineAsHeader: true,
 	}
 }
 
+// trimFields strips the whitespace around each field of record in place
+// when TrimSpace is set, and returns record
+func (p *CSVParser) trimFields(record []string) []string {
+	if p.TrimSpace {
+		for i, field := range record {
+			record[i] = strings.TrimSpace(field)
+		}
+	}
+	return record
+}
+
+// checkComment rejects a comment character that is also the delimiter,
+// which encoding/csv only reports as an invalid delimiter
+func (p *CSVParser) checkComment() error {
+	if p.Comment != 0 && p.Comment == p.Comma {
+		return fmt.Errorf("the comment character %q is also the delimiter", p.Comment)
+	}
+	return nil
+}
+
+// trimLeadingSpace reports whether encoding/csv may drop the whitespace
+// before each field. It would also swallow a whitespace delimiter, merging
+// empty fields of a TSV, so there trimFields alone trims the cells.
+func (p *CSVParser) trimLeadingSpace() bool {
+	return p.TrimSpace && !unicode.IsSpace(p.Comma)
+}
+
 // Parse parses CSV data from a byte array
 func (p *CSVParser) Parse(data []byte) (*CSVData, error) {
-	reader := csv.NewReader(strings.NewReader(string(data)))
+	if err := p.checkComment(); err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(bytes.NewReader(stripBOM(data)))
 	reader.Comma = p.Comma
 	reader.Comment = p.Comment
+	reader.TrimLeadingSpace = p.trimLeadingSpace()
+	reader.FieldsPerRecord = -1 // Accept ragged rows; normalizeRows evens them out
 
 	csvData := NewCSVData()
 
@@ -61,28 +111,21 @@ func (p *CSVParser) Parse(data []byte) (*CSVData, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse CSV: %w", err)
 	}
+	for _, record := range records {
+		p.trimFields(record)
+	}
 
 	if len(records) == 0 {
 		return csvData, nil
 	}
 
-	// Extract headers if configured
+	// Extract headers if the first row holds them
 	startRow := 0
-	if p.UseFirstLineAsHeader {
-		csvData.Headers = records[0]
+	if p.firstRowIsHeader(records[0], records[1:]) {
+		csvData.setHeaders(records[0])
 		startRow = 1
 	} else {
-		// Generate default headers (Column 1, Column 2, etc.)
-		csvData.Headers = make([]string, len(records[0]))
-		for i := range csvData.Headers {
-			csvData.Headers[i] = fmt.Sprintf("Column %d", i+1)
-		}
-	}
-
-	// Initialize column visibility (all visible by default)
-	csvData.ColumnVisibility = make([]bool, len(csvData.Headers))
-	for i := range csvData.ColumnVisibility {
-		csvData.ColumnVisibility[i] = true
+		csvData.setHeaders(generatedHeaders(len(records[0])))
 	}
 
 	// Add data rows
@@ -90,37 +133,57 @@ func (p *CSVParser) Parse(data []byte) (*CSVData, error) {
 		csvData.Rows = append(csvData.Rows, records[i])
 	}
 
-	// Calculate column widths for display formatting
+	// Even out ragged rows, then calculate column widths for display formatting
+	csvData.normalizeRows()
 	csvData.calculateColumnWidths()
 
 	return csvData, nil
 }
 
-// ParseStream parses CSV data from an io.Reader
+// ParseStream parses CSV data from an io.Reader.
+// Records are read one at a time, so peak memory is the parsed rows plus the
+// reader's buffer; the raw input is never held in full as it is with Parse.
 func (p *CSVParser) ParseStream(reader io.Reader) (*CSVData, error) {
-	csvReader := csv.NewReader(reader)
+	if err := p.checkComment(); err != nil {
+		return nil, err
+	}
+	csvReader := csv.NewReader(stripBOMReader(reader))
 	csvReader.Comma = p.Comma
 	csvReader.Comment = p.Comment
+	csvReader.TrimLeadingSpace = p.trimLeadingSpace()
+	csvReader.FieldsPerRecord = -1 // Accept ragged rows; normalizeRows evens them out
 
 	csvData := NewCSVData()
 
-	// Read headers if configured
-	if p.UseFirstLineAsHeader {
-		headers, err := csvReader.Read()
+	// Read the first row, and in auto mode the rows it is compared with
+	first, err := csvReader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return csvData, nil
+		}
+		return nil, fmt.Errorf("failed to read CSV headers: %w", err)
+	}
+	p.trimFields(first)
+
+	var sample [][]string
+	for p.headerMode() == HeaderAuto && len(sample) < headerSampleRows {
+		record, err := csvReader.Read()
 		if err != nil {
 			if err == io.EOF {
-				return csvData, nil
+				break
 			}
-			return nil, fmt.Errorf("failed to read CSV headers: %w", err)
+			return nil, fmt.Errorf("failed to read CSV row: %w", err)
 		}
-		csvData.Headers = headers
+		sample = append(sample, p.trimFields(record))
+	}
 
-		// Initialize column visibility
-		csvData.ColumnVisibility = make([]bool, len(headers))
-		for i := range csvData.ColumnVisibility {
-			csvData.ColumnVisibility[i] = true
-		}
+	if p.firstRowIsHeader(first, sample) {
+		csvData.setHeaders(first)
+	} else {
+		csvData.setHeaders(generatedHeaders(len(first)))
+		csvData.Rows = append(csvData.Rows, first)
 	}
+	csvData.Rows = append(csvData.Rows, sample...)
 
 	// Read data rows
 	for {
@@ -131,45 +194,63 @@ func (p *CSVParser) ParseStream(reader io.Reader) (*CSVData, error) {
 			}
 			return nil, fmt.Errorf("failed to read CSV row: %w", err)
 		}
-
-		// If we haven't read headers yet, generate them based on the first row
-		if len(csvData.Headers) == 0 {
-			csvData.Headers = make([]string, len(record))
-			for i := range csvData.Headers {
-				csvData.Headers[i] = fmt.Sprintf("Column %d", i+1)
-			}
-
-			// Initialize column visibility
-			csvData.ColumnVisibility = make([]bool, len(csvData.Headers))
-			for i := range csvData.ColumnVisibility {
-				csvData.ColumnVisibility[i] = true
-			}
-		}
-
+		p.trimFields(record)
 		csvData.Rows = append(csvData.Rows, record)
 	}
 
-	// Calculate column widths
+	// Even out ragged rows, then calculate column widths
+	csvData.normalizeRows()
 	csvData.calculateColumnWidths()
 
 	return csvData, nil
 }
 
-// calculateColumnWidths updates the ColumnWidths field based on the current data
-func (c *CSVData) calculateColumnWidths() {
-	// Initialize column widths based on headers
-	c.ColumnWidths = make([]int, len(c.Headers))
-	for i, header := range c.Headers {
-		c.ColumnWidths[i] = len(header)
+// setHeaders names the columns, all of them visible
+func (c *CSVData) setHeaders(headers []string) {
+	c.Headers = headers
+	c.ColumnVisibility = make([]bool, len(headers))
+	for i := range c.ColumnVisibility {
+		c.ColumnVisibility[i] = true
 	}
+}
+
+// generatedHeaders returns the headers of n columns that have no names:
+// Column 1, Column 2 and so on
+func generatedHeaders(n int) []string {
+	headers := make([]string, n)
+	for i := range headers {
+		headers[i] = fmt.Sprintf("Column %d", i+1)
+	}
+	return headers
+}
 
-	// Update column widths based on data
+// normalizeRows makes every row as wide as the header. Rows with extra
+// fields get generated "Column N" headers so their cells are shown rather
+// than dropped, and short rows are padded with empty cells.
+func (c *CSVData) normalizeRows() {
 	for _, row := range c.Rows {
-		for i, cell := range row {
-			if i < len(c.ColumnWidths) && len(cell) > c.ColumnWidths[i] {
-				c.ColumnWidths[i] = len(cell)
-			}
+		for len(c.Headers) < len(row) {
+			c.Headers = append(c.Headers, fmt.Sprintf("Column %d", len(c.Headers)+1))
+			c.ColumnVisibility = append(c.ColumnVisibility, true)
+		}
+	}
+
+	for i, row := range c.Rows {
+		for len(row) < len(c.Headers) {
+			row = append(row, "")
 		}
+		c.Rows[i] = row
+	}
+}
+
+// calculateColumnWidths updates the ColumnWidths field based on the current data
+func (c *CSVData) calculateColumnWidths() {
+	// Measure the data, then widen columns whose header is longer still
+	c.ColumnWidths = MaxCellWidths(c.Rows, len(c.Headers), func(_ int, cell string) int {
+		return len(cell)
+	})
+	for i, header := range c.Headers {
+		c.ColumnWidths[i] = max(c.ColumnWidths[i], len(header))
 	}
 }
 
@@ -180,6 +261,20 @@ func (c *CSVData) ToggleColumnVisibility(colIndex int) {
 	}
 }
 
+// InvertColumnVisibility hides every visible column and shows every hidden one
+func (c *CSVData) InvertColumnVisibility() {
+	for i := range c.ColumnVisibility {
+		c.ColumnVisibility[i] = !c.ColumnVisibility[i]
+	}
+}
+
+// ShowAllColumns makes every column visible
+func (c *CSVData) ShowAllColumns() {
+	for i := range c.ColumnVisibility {
+		c.ColumnVisibility[i] = true
+	}
+}
+
 // GetVisibleColumns returns indices of visible columns
 func (c *CSVData) GetVisibleColumns() []int {
 	var visible []int
@@ -208,24 +303,49 @@ func (c *CSVData) SortByColumn(colIndex int, ascending bool) {
 	c.SortColumn = colIndex
 	c.SortAsc = ascending
 
-	// Simple string comparison sort
-	if ascending {
-		for i := 0; i < len(c.Rows)-1; i++ {
-			for j := i + 1; j < len(c.Rows); j++ {
-				if colIndex < len(c.Rows[i]) && colIndex < len(c.Rows[j]) &&
-					c.Rows[i][colIndex] > c.Rows[j][colIndex] {
-					c.Rows[i], c.Rows[j] = c.Rows[j], c.Rows[i]
-				}
-			}
-		}
-	} else {
-		for i := 0; i < len(c.Rows)-1; i++ {
-			for j := i + 1; j < len(c.Rows); j++ {
-				if colIndex < len(c.Rows[i]) && colIndex < len(c.Rows[j]) &&
-					c.Rows[i][colIndex] < c.Rows[j][colIndex] {
-					c.Rows[i], c.Rows[j] = c.Rows[j], c.Rows[i]
-				}
-			}
+	// Compare using the inferred column type so numbers and dates order naturally
+	columnType := c.ColumnTypeOf(colIndex)
+	order := make([]int, len(c.Rows))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		cmp := compareCells(cellAt(c.Rows[order[i]], colIndex), cellAt(c.Rows[order[j]], colIndex), columnType)
+		if ascending {
+			return cmp < 0
 		}
+		return cmp > 0
+	})
+
+	// Apply the permutation to the rows and their original positions together
+	rows := make([][]string, len(c.Rows))
+	rowOrder := make([]int, len(c.Rows))
+	for i, from := range order {
+		rows[i] = c.Rows[from]
+		rowOrder[i] = c.originalIndex(from)
+	}
+	c.Rows = rows
+	c.rowOrder = rowOrder
+}
+
+// RowNumber returns the 1-based position the row at rowIndex had in the
+// input, which stays the same however the rows are sorted
+func (c *CSVData) RowNumber(rowIndex int) int {
+	return c.originalIndex(rowIndex) + 1
+}
+
+// originalIndex returns the 0-based input position of the row at rowIndex
+func (c *CSVData) originalIndex(rowIndex int) int {
+	if rowIndex < len(c.rowOrder) {
+		return c.rowOrder[rowIndex]
+	}
+	return rowIndex
+}
+
+// cellAt returns the cell at colIndex, or an empty string for short rows
+func cellAt(row []string, colIndex int) string {
+	if colIndex < len(row) {
+		return row[colIndex]
 	}
+	return ""
 }
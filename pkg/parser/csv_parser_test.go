@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+// parseBoth parses data with Parse and with ParseStream, returning both tables
+func parseBoth(t *testing.T, p *CSVParser, data []byte) map[string]*CSVData {
+	t.Helper()
+	parsed, err := p.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	streamed, err := p.ParseStream(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseStream: %v", err)
+	}
+	return map[string]*CSVData{"Parse": parsed, "ParseStream": streamed}
+}
+
+func TestTrimSpace(t *testing.T) {
+	data := []byte("id, status \n1, active \n")
+	for _, trim := range []bool{true, false} {
+		p := NewCSVParser()
+		p.TrimSpace = trim
+		wantHeader, wantCell := " status ", " active "
+		if trim {
+			wantHeader, wantCell = "status", "active"
+		}
+
+		for name, table := range parseBoth(t, p, data) {
+			if got := table.Headers[1]; got != wantHeader {
+				t.Errorf("%s with TrimSpace %v: got header %q, want %q", name, trim, got, wantHeader)
+			}
+			if got := table.Rows[0][1]; got != wantCell {
+				t.Errorf("%s with TrimSpace %v: got cell %q, want %q", name, trim, got, wantCell)
+			}
+		}
+	}
+
+	// A tab delimiter is whitespace too, but trimming must keep the empty
+	// field between two tabs rather than shift the later cells left
+	p := NewCSVParser()
+	p.Comma = '\t'
+	p.TrimSpace = true
+	for name, table := range parseBoth(t, p, []byte("a\tb\tc\n1\t\t 3 \n")) {
+		if got, want := table.Rows[0], []string{"1", "", "3"}; !slices.Equal(got, want) {
+			t.Errorf("%s with a tab delimiter: got row %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestStripBOM(t *testing.T) {
+	for name, table := range parseBoth(t, NewCSVParser(), readFixture(t, "bom.csv")) {
+		if got := table.Headers[0]; got != "Name" {
+			t.Errorf("%s: got first header %q, want %q without the byte order mark", name, got, "Name")
+		}
+	}
+}
+
+func TestRaggedRows(t *testing.T) {
+	wantHeaders := []string{"id", "name", "email", "role", "Column 5"}
+	for name, table := range parseBoth(t, NewCSVParser(), readFixture(t, "ragged.csv")) {
+		// The row with an extra field gets a generated header for it
+		if !slices.Equal(table.Headers, wantHeaders) {
+			t.Errorf("%s: got headers %q, want %q", name, table.Headers, wantHeaders)
+		}
+		if len(table.Rows) != 7 {
+			t.Fatalf("%s: got %d rows, want 7", name, len(table.Rows))
+		}
+		for i, row := range table.Rows {
+			if len(row) != len(table.Headers) {
+				t.Errorf("%s: row %d has %d cells, want %d", name, i, len(row), len(table.Headers))
+			}
+		}
+
+		// Short rows are padded and long ones keep their extra cell
+		if got := table.Rows[1]; !slices.Equal(got, []string{"2", "Bob", "", "", ""}) {
+			t.Errorf("%s: got short row %q", name, got)
+		}
+		if got := table.Rows[2][4]; got != "extra" {
+			t.Errorf("%s: got extra cell %q, want %q", name, got, "extra")
+		}
+		if got := table.Rows[5]; !slices.Equal(got, []string{"6", "", "", "", ""}) {
+			t.Errorf("%s: got one-field row %q", name, got)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/csv"
 	"encoding/json"
+	"io"
 	"strings"
 )
 
@@ -20,6 +21,12 @@ const (
 	FormatJSONL
 	// FormatCSV represents CSV format
 	FormatCSV
+	// FormatYAML represents YAML format
+	FormatYAML
+	// FormatTOML represents TOML format
+	FormatTOML
+	// FormatTSV represents tab-separated values
+	FormatTSV
 )
 
 // File format string representations for consistent usage
@@ -27,6 +34,9 @@ const (
 	TypeJSON    = "json"
 	TypeJSONL   = "jsonl"
 	TypeCSV     = "csv"
+	TypeYAML    = "yaml"
+	TypeTOML    = "toml"
+	TypeTSV     = "tsv"
 	TypeUnknown = "unknown"
 )
 
@@ -39,6 +49,12 @@ func (f FileFormat) String() string {
 		return "JSONL"
 	case FormatCSV:
 		return "CSV"
+	case FormatYAML:
+		return "YAML"
+	case FormatTOML:
+		return "TOML"
+	case FormatTSV:
+		return "TSV"
 	default:
 		return "Unknown"
 	}
@@ -53,6 +69,12 @@ func (f FileFormat) ToTypeString() string {
 		return TypeJSONL
 	case FormatCSV:
 		return TypeCSV
+	case FormatYAML:
+		return TypeYAML
+	case FormatTOML:
+		return TypeTOML
+	case FormatTSV:
+		return TypeTSV
 	default:
 		return TypeUnknown
 	}
@@ -68,16 +90,106 @@ func DetectFormat(data []byte, extension string) FileFormat {
 		return FormatJSONL
 	case ".csv":
 		return FormatCSV
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	case ".tsv":
+		return FormatTSV
 	}
 
 	// If extension doesn't conclusively determine format, inspect the content
-	return detectByContent(data)
+	format, _ := detectByContent(data)
+	return format
 }
 
 // DetectFileType returns a string representation of the file type
 // This is a helper function used by the UI to determine which viewer to use
 func DetectFileType(data []byte) string {
-	return detectByContent(data).ToTypeString()
+	fileType, _ := DetectFileTypeWithDelimiter(data)
+	return fileType
+}
+
+// DetectFileTypeWithDelimiter returns the file type along with the detected
+// field delimiter, so CSV parsing can reuse it instead of re-detecting.
+// The delimiter is 0 for non-CSV content.
+func DetectFileTypeWithDelimiter(data []byte) (string, rune) {
+	format, delimiter := detectByContent(data)
+	return format.ToTypeString(), delimiter
+}
+
+// ExtensionFileType returns the file type implied by an extension for formats
+// that content detection can't reliably recognize, or "" otherwise
+func ExtensionFileType(extension string) string {
+	switch strings.ToLower(extension) {
+	case ".jsonl":
+		// A single record reads as plain JSON, so trust the extension
+		return TypeJSONL
+	case ".yaml", ".yml":
+		return TypeYAML
+	case ".toml":
+		return TypeTOML
+	case ".tsv":
+		return TypeTSV
+	}
+	return ""
+}
+
+// MediaTypeFileType returns the file type named by a Content-Type media
+// type, e.g. application/json, or "" for types that don't name one, such as
+// text/plain
+func MediaTypeFileType(mediaType string) string {
+	mediaType = strings.ToLower(mediaType)
+	switch mediaType {
+	case "application/json":
+		return TypeJSON
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return TypeJSONL
+	case "text/csv":
+		return TypeCSV
+	case "text/tab-separated-values":
+		return TypeTSV
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return TypeYAML
+	case "application/toml":
+		return TypeTOML
+	}
+
+	// Structured suffixes, e.g. application/problem+json
+	if strings.HasSuffix(mediaType, "+json") {
+		return TypeJSON
+	}
+	return ""
+}
+
+// DetectSampleSize is how many leading bytes of a streamed input are
+// inspected to detect its format
+const DetectSampleSize = 64 * 1024
+
+// DetectSampleType detects the file type from the leading bytes of an input.
+// When truncated is true the sample is only a prefix, so the last partial
+// line is ignored and a document opening with { or [ is assumed to be JSON
+// even though it can't be fully validated.
+func DetectSampleType(sample []byte, truncated bool) (string, rune) {
+	if !truncated {
+		return DetectFileTypeWithDelimiter(sample)
+	}
+
+	// Drop the trailing partial line, if there is more than one line
+	if idx := bytes.LastIndexByte(sample, '\n'); idx > 0 {
+		sample = sample[:idx]
+	}
+
+	trimmed := bytes.TrimSpace(stripBOM(sample))
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		lines := splitAllLines(trimmed)
+		if format, _, ok := detectJSONLFormat(trimmed, lines); ok {
+			return format.ToTypeString(), 0
+		}
+		return TypeJSON, 0
+	}
+
+	return DetectFileTypeWithDelimiter(sample)
 }
 
 // detectJSONFormat attempts to detect JSON format from data
@@ -92,91 +204,258 @@ func detectJSONFormat(data []byte) (FileFormat, bool) {
 	return FormatUnknown, false
 }
 
-// detectJSONLFormat attempts to detect JSONL format from data
-func detectJSONLFormat(lines [][]byte) (FileFormat, bool) {
+// JSONL detection defaults: how many leading lines are sampled, and the share
+// of the non-empty sampled lines that must be JSON records
+const (
+	jsonlSampleLines = 10
+	jsonlThreshold   = 0.5
+)
+
+// detectJSONLFormat attempts to detect JSONL format from data, split into
+// lines, using the default sample size and threshold
+func detectJSONLFormat(data []byte, lines [][]byte) (FileFormat, float64, bool) {
+	return detectJSONLFormatWith(data, lines, jsonlSampleLines, jsonlThreshold)
+}
+
+// detectJSONLFormatWith reports JSONL when at least threshold of the
+// non-empty lines among the first sampleLines are JSON objects or arrays,
+// along with the share of those lines that were
+func detectJSONLFormatWith(data []byte, lines [][]byte, sampleLines int, threshold float64) (FileFormat, float64, bool) {
 	if len(lines) <= 1 {
-		return FormatUnknown, false
+		return FormatUnknown, 0, false
 	}
 
-	// Check a sample of lines (up to 10)
-	sampleSize := min(10, len(lines))
-	jsonlCount := 0
+	sampleSize := min(sampleLines, len(lines))
+	checked, jsonlCount := 0, 0
+	firstValid := false
 
 	for _, line := range lines[:sampleSize] {
 		line = bytes.TrimSpace(line)
 		if len(line) == 0 {
 			continue
 		}
+		checked++
 
-		if line[0] == '{' {
+		// Records are objects or arrays; bare scalars are too ambiguous
+		valid := false
+		if line[0] == '{' || line[0] == '[' {
 			var js interface{}
-			if json.Unmarshal(line, &js) == nil {
-				jsonlCount++
+			valid = json.Unmarshal(line, &js) == nil
+		}
+		if valid {
+			jsonlCount++
+		}
+		if checked == 1 {
+			firstValid = valid
+		}
+	}
+
+	ratio := float64(jsonlCount) / float64(max(checked, 1))
+	if jsonlCount == 0 || ratio < threshold {
+		return FormatUnknown, 0, false
+	}
+
+	// Physical lines inside a quoted, multi-line CSV field can look like JSON,
+	// so content that doesn't open with a record must not read as CSV. Any
+	// other bad first line, like a status message, only counts against the
+	// threshold.
+	if !firstValid && opensCSV(data) {
+		return FormatUnknown, 0, false
+	}
+	return FormatJSONL, ratio, true
+}
+
+// opensCSV reports whether data reads as CSV: with the delimiter detected
+// for it, either the first record has as many fields as most of the others,
+// as a header does, or a quoted field of the sampled records spans lines
+func opensCSV(data []byte) bool {
+	_, delimiter, _, ok := detectCSVFormat(data)
+	if !ok {
+		return false
+	}
+	_, fields := scoreDelimiter(data, delimiter)
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = delimiter
+	r.FieldsPerRecord = -1
+	for i := 0; i < csvSampleRecords; i++ {
+		record, err := r.Read()
+		if err != nil {
+			return false
+		}
+		if i == 0 && len(record) == fields {
+			return true
+		}
+		for _, field := range record {
+			if strings.Contains(field, "\n") {
+				return true
 			}
 		}
 	}
+	return false
+}
+
+// candidateDelimiters lists the delimiters tried when detecting CSV content
+var candidateDelimiters = []rune{',', '\t', ';', '|'}
+
+// csvSampleRecords is the number of records inspected per candidate delimiter
+const csvSampleRecords = 20
+
+// detectCSVFormat attempts to detect CSV format from data and returns the
+// delimiter that produced the most consistent field counts, with the share of
+// rows that agreed. Tab-delimited data is reported as FormatTSV.
+func detectCSVFormat(data []byte) (FileFormat, rune, float64, bool) {
+	bestDelimiter := rune(0)
+	bestScore := 0.0
+	bestFields := 0
+
+	for _, delimiter := range candidateDelimiters {
+		score, fields := scoreDelimiter(data, delimiter)
+		if score > bestScore || (score == bestScore && fields > bestFields) {
+			bestDelimiter = delimiter
+			bestScore = score
+			bestFields = fields
+		}
+	}
 
-	// If most (>50%) of the tested lines are valid JSON objects, assume JSONL
-	if jsonlCount > 0 && jsonlCount >= sampleSize/2 {
-		return FormatJSONL, true
+	// If most (>50%) rows agree on a field count of at least 2, assume it's a CSV
+	if bestDelimiter != 0 && bestScore > 0.5 {
+		if bestDelimiter == '\t' {
+			return FormatTSV, bestDelimiter, bestScore, true
+		}
+		return FormatCSV, bestDelimiter, bestScore, true
 	}
 
-	return FormatUnknown, false
+	return FormatUnknown, 0, 0, false
 }
 
-// detectCSVFormat attempts to detect CSV format from data
-func detectCSVFormat(data []byte) (FileFormat, bool) {
+// scoreDelimiter reads a sample of records using the given delimiter and returns
+// the fraction of rows sharing the most common field count, along with that count
+func scoreDelimiter(data []byte, delimiter rune) (float64, int) {
 	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = delimiter
 	r.FieldsPerRecord = -1 // Allow variable number of fields
-	records, err := r.ReadAll()
 
-	if err != nil || len(records) <= 1 {
-		return FormatUnknown, false
+	// Count how many sampled rows have each field count
+	counts := make(map[int]int)
+	total := 0
+	for total < csvSampleRecords {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// A parse error in the first record means the delimiter doesn't fit;
+			// later errors are usually a truncated sample, so keep what we have
+			if total == 0 {
+				return 0, 0
+			}
+			break
+		}
+		counts[len(record)]++
+		total++
+	}
+
+	if total <= 1 {
+		return 0, 0
 	}
 
-	// Count valid rows (rows with at least 2 fields)
-	validRows := 0
-	for _, record := range records {
-		if len(record) >= 2 {
-			validRows++
+	// Find the most common field count, ignoring single-field rows
+	modeFields, modeCount := 0, 0
+	for fields, count := range counts {
+		if fields < 2 {
+			continue
+		}
+		if count > modeCount || (count == modeCount && fields > modeFields) {
+			modeFields, modeCount = fields, count
 		}
 	}
 
-	// If most (>50%) rows are valid, assume it's a CSV
-	if validRows > len(records)/2 {
-		return FormatCSV, true
+	if modeCount == 0 {
+		return 0, 0
 	}
 
-	return FormatUnknown, false
+	return float64(modeCount) / float64(total), modeFields
+}
+
+// Details describes how a format was detected
+type Details struct {
+	// Delimiter is the field delimiter of CSV and TSV content, or 0
+	Delimiter rune
+	// Lines is the number of lines in the content, ignoring surrounding blank lines
+	Lines int
+	// Confidence runs from 0 to 1: 1 for content that parsed as the format,
+	// lower for heuristic matches, and 0 when the format is unknown
+	Confidence float64
 }
 
-// detectByContent analyzes the file content to determine its format
-func detectByContent(data []byte) FileFormat {
-	// Trim whitespace
-	trimmed := bytes.TrimSpace(data)
+// Confidence of the formats recognized by heuristics rather than a full parse
+const (
+	tomlConfidence       = 0.8
+	yamlConfidence       = 0.5 // YAML accepts almost anything
+	brokenJSONConfidence = 0.3
+)
+
+// DetectFormatDetailed detects the format of data by its content and reports
+// how sure the detection is, so callers can decide whether to trust it
+func DetectFormatDetailed(data []byte) (FileFormat, Details) {
+	// Trim a byte order mark and whitespace
+	trimmed := bytes.TrimSpace(stripBOM(data))
 	if len(trimmed) == 0 {
-		return FormatUnknown
+		return FormatUnknown, Details{}
 	}
 
+	// Split into lines for JSONL detection
+	lines := splitAllLines(trimmed)
+	details := Details{Lines: len(lines)}
+
 	// Try to detect JSON first (fastest check)
 	if format, ok := detectJSONFormat(trimmed); ok {
-		return format
+		details.Confidence = 1
+		return format, details
 	}
 
-	// Split into lines for JSONL detection
-	lines := bytes.Split(trimmed, []byte("\n"))
-
 	// Try to detect JSONL
-	if format, ok := detectJSONLFormat(lines); ok {
-		return format
+	if format, ratio, ok := detectJSONLFormat(trimmed, lines); ok {
+		details.Confidence = ratio
+		return format, details
+	}
+
+	// TOML section headers and assignments are distinctive, check before CSV
+	if looksLikeTOML(trimmed) {
+		details.Confidence = tomlConfidence
+		return FormatTOML, details
+	}
+
+	// Try to detect CSV (can be expensive for large files)
+	if format, delimiter, score, ok := detectCSVFormat(trimmed); ok {
+		details.Delimiter = delimiter
+		details.Confidence = score
+		return format, details
+	}
+
+	// Content that opens like JSON but matched nothing is most likely broken
+	// JSON, so let the JSON parser report where it goes wrong. YAML would
+	// read it as a flow mapping or sequence and hide the mistake.
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		details.Confidence = brokenJSONConfidence
+		return FormatJSON, details
 	}
 
-	// Try to detect CSV last (can be expensive for large files)
-	if format, ok := detectCSVFormat(trimmed); ok {
-		return format
+	// YAML accepts almost anything, so only consider it last
+	if looksLikeYAML(trimmed) {
+		details.Confidence = yamlConfidence
+		return FormatYAML, details
 	}
 
-	return FormatUnknown
+	return FormatUnknown, details
+}
+
+// detectByContent analyzes the file content to determine its format.
+// For CSV content the detected delimiter is returned as well.
+func detectByContent(data []byte) (FileFormat, rune) {
+	format, details := DetectFormatDetailed(data)
+	return format, details.Delimiter
 }
 
 // Helper function for min value
@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"tablux/pkg/model"
+)
+
+// readFixture reads a file from the repository's test directory
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("../../test/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDetectBrokenJSON(t *testing.T) {
+	for _, input := range []string{
+		"{\"a\": 1,\n \"b\": }",
+		"[1, 2,\n 3,]",
+		"{\"name\": \"x\"\n\"age\": 3}",
+	} {
+		if got := DetectFileType([]byte(input)); got != TypeJSON {
+			t.Errorf("%q detected as %s, want %s", input, got, TypeJSON)
+			continue
+		}
+		if _, err := NewJSONParser().Parse([]byte(input)); err == nil || !strings.Contains(err.Error(), "line ") {
+			t.Errorf("%q: got error %v, want one giving the line", input, err)
+		}
+	}
+}
+
+func TestCRLFLineEndings(t *testing.T) {
+	csvData := readFixture(t, "crlf.csv")
+	if got := DetectFileType(csvData); got != TypeCSV {
+		t.Fatalf("crlf.csv detected as %s, want %s", got, TypeCSV)
+	}
+	table, err := NewCSVParser().Parse(csvData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cell := range append(table.Headers, slices.Concat(table.Rows...)...) {
+		if strings.HasSuffix(cell, "\r") {
+			t.Errorf("CSV cell %q ends in a carriage return", cell)
+		}
+	}
+
+	jsonlData := readFixture(t, "crlf.jsonl")
+	if got := DetectFileType(jsonlData); got != TypeJSONL {
+		t.Fatalf("crlf.jsonl detected as %s, want %s", got, TypeJSONL)
+	}
+	root, err := NewJSONParser().ParseJSONL(jsonlData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var check func(node *model.JSONNode)
+	check = func(node *model.JSONNode) {
+		if strings.HasSuffix(node.Key, "\r") {
+			t.Errorf("JSONL key %q ends in a carriage return", node.Key)
+		}
+		if value, ok := node.Value.(string); ok && strings.HasSuffix(value, "\r") {
+			t.Errorf("JSONL value %q at %s ends in a carriage return", value, node.DisplayPath())
+		}
+		for _, child := range node.Children {
+			check(child)
+		}
+	}
+	check(root)
+	if len(root.Children) != 3 {
+		t.Errorf("got %d JSONL records, want 3", len(root.Children))
+	}
+}
+
+func TestDetectTSV(t *testing.T) {
+	if got, delimiter := DetectFileTypeWithDelimiter(readFixture(t, "sample.tsv")); got != TypeTSV || delimiter != '\t' {
+		t.Errorf("sample.tsv detected as %s with delimiter %q, want %s with a tab", got, delimiter, TypeTSV)
+	}
+}
+
+func TestDetectCSVWithEmbeddedNewlines(t *testing.T) {
+	// Quoted fields spanning lines must not make the rows look uneven, or
+	// individual lines look like something else
+	for _, name := range []string{"multiline.csv", "quoted.csv"} {
+		if got := DetectFileType(readFixture(t, name)); got != TypeCSV {
+			t.Errorf("%s detected as %s, want %s", name, got, TypeCSV)
+		}
+	}
+}
+
+func TestDetectJSONLWithBadFirstLine(t *testing.T) {
+	// A bad first line counts against the threshold like any other, unless
+	// the content reads as CSV from its header
+	for name, input := range map[string]string{
+		"status line":    "export started\n{\"id\": 1}\n{\"id\": 2}\n{\"id\": 3}\n",
+		"truncated line": "{\"id\": 0, \"name\": \"cut\n{\"id\": 1}\n{\"id\": 2}\n",
+	} {
+		if got := DetectFileType([]byte(input)); got != TypeJSONL {
+			t.Errorf("%s detected as %s, want %s", name, got, TypeJSONL)
+		}
+	}
+}
+
+func TestDetectJSONLineShapes(t *testing.T) {
+	for name, input := range map[string]string{
+		"arrays":        "[1, \"a\"]\n[2, \"b\"]\n[3, \"c\"]\n",
+		"indented":      "  {\"id\": 1}\n\t{\"id\": 2}\n    {\"id\": 3}\n",
+		"arrays.jsonl":  string(readFixture(t, "arrays.jsonl")),
+		"mixed objects": "{\"id\": 1}\n  [2]\n{\"id\": 3}\n",
+	} {
+		if got := DetectFileType([]byte(input)); got != TypeJSONL {
+			t.Errorf("%s detected as %s, want %s", name, got, TypeJSONL)
+		}
+	}
+}
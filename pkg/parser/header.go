@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// HeaderMode decides whether the first CSV row names the columns
+type HeaderMode int
+
+// Header mode constants
+const (
+	// HeaderAlways reads the first row as the header
+	HeaderAlways HeaderMode = iota
+	// HeaderNever reads every row as data, under generated "Column N" headers
+	HeaderNever
+	// HeaderAuto reads the first row as the header when it looks like one
+	HeaderAuto
+)
+
+// headerSampleRows is how many rows after the first HeaderAuto compares it with
+const headerSampleRows = 20
+
+// headerModeNames are the names ParseHeaderMode accepts
+var headerModeNames = map[HeaderMode]string{
+	HeaderAlways: "always",
+	HeaderNever:  "never",
+	HeaderAuto:   "auto",
+}
+
+// String returns the name of the header mode
+func (m HeaderMode) String() string {
+	return headerModeNames[m]
+}
+
+// ParseHeaderMode returns the header mode called name: always, never or auto
+func ParseHeaderMode(name string) (HeaderMode, error) {
+	for mode, modeName := range headerModeNames {
+		if strings.EqualFold(name, modeName) {
+			return mode, nil
+		}
+	}
+	return HeaderAlways, fmt.Errorf("unknown header mode %q", name)
+}
+
+// headerMode returns the header mode in effect, with the deprecated
+// UseFirstLineAsHeader turning HeaderAlways into HeaderNever when false
+func (p *CSVParser) headerMode() HeaderMode {
+	if p.Header == HeaderAlways && !p.UseFirstLineAsHeader {
+		return HeaderNever
+	}
+	return p.Header
+}
+
+// firstRowIsHeader reports whether first names the columns under the
+// parser's header mode, with rows being the data that follows it
+func (p *CSVParser) firstRowIsHeader(first []string, rows [][]string) bool {
+	switch p.headerMode() {
+	case HeaderNever:
+		return false
+	case HeaderAuto:
+		return looksLikeHeader(first, rows[:min(len(rows), headerSampleRows)])
+	default:
+		return true
+	}
+}
+
+// looksLikeHeader guesses whether first is a header row. Column names are
+// unique and never numbers or dates, so a row with either is data. Beyond
+// that each column votes: a text name above numbers, or a name whose length
+// differs from the fixed-length codes below it, counts for a header, and a
+// row matching such codes counts against. A tie keeps the first row as the
+// header, as most CSV files have one.
+func looksLikeHeader(first []string, rows [][]string) bool {
+	seen := make(map[string]bool, len(first))
+	for _, cell := range first {
+		cell = strings.TrimSpace(cell)
+		if cell == "" {
+			continue
+		}
+		if seen[cell] || isTypedCell(cell) {
+			return false
+		}
+		seen[cell] = true
+	}
+
+	votes := 0
+	for col, name := range first {
+		numeric, length, sameLength, cells := true, -1, true, 0
+		for _, row := range rows {
+			if col >= len(row) {
+				continue
+			}
+			cell := strings.TrimSpace(row[col])
+			if cell == "" {
+				continue
+			}
+			cells++
+			if _, err := strconv.ParseFloat(cell, 64); err != nil {
+				numeric = false
+			}
+			if n := utf8.RuneCountInString(cell); length < 0 {
+				length = n
+			} else if n != length {
+				sameLength = false
+			}
+		}
+
+		switch {
+		case cells == 0:
+			// An empty column says nothing about its name
+		case numeric:
+			votes++
+		case sameLength && utf8.RuneCountInString(strings.TrimSpace(name)) != length:
+			votes++
+		case sameLength:
+			votes--
+		}
+	}
+	return votes >= 0
+}
+
+// isTypedCell reports whether cell holds a number or a date rather than text
+func isTypedCell(cell string) bool {
+	if _, err := strconv.ParseFloat(cell, 64); err == nil {
+		return true
+	}
+	_, ok := parseDate(cell)
+	return ok
+}
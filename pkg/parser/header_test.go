@@ -0,0 +1,35 @@
+package parser
+
+import "testing"
+
+func TestLooksLikeHeader(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		first []string
+		rows  [][]string
+		want  bool
+	}{
+		{"numeric first row", []string{"1", "2.5"}, [][]string{{"3", "4"}}, false},
+		{"date in first row", []string{"2024-01-02", "open"}, [][]string{{"2024-01-03", "closed"}}, false},
+		{"duplicate names", []string{"name", "name"}, [][]string{{"1", "2"}}, false},
+		{"text over numbers", []string{"id", "score"}, [][]string{{"1", "2.5"}, {"2", "3"}}, true},
+		{"codes over codes", []string{"US", "FR"}, [][]string{{"DE", "IT"}, {"GB", "ES"}}, false},
+		// The first column votes for a header and the second against
+		{"tie", []string{"code", "xy"}, [][]string{{"AB", "ab"}, {"CD", "cd"}}, true},
+		{"no rows", []string{"id", "name"}, nil, true},
+	} {
+		if got := looksLikeHeader(test.first, test.rows); got != test.want {
+			t.Errorf("%s: looksLikeHeader(%q) = %v, want %v", test.name, test.first, got, test.want)
+		}
+	}
+}
+
+func TestUseFirstLineAsHeader(t *testing.T) {
+	p := NewCSVParser()
+	p.UseFirstLineAsHeader = false
+	for name, table := range parseBoth(t, p, []byte("id,name\n1,Ada\n")) {
+		if len(table.Rows) != 2 || table.Headers[0] != "Column 1" {
+			t.Errorf("%s: got headers %q and %d rows, want the first row read as data", name, table.Headers, len(table.Rows))
+		}
+	}
+}
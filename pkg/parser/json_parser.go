@@ -1,15 +1,31 @@
 package parser
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
 
 	"tablux/pkg/model"
 )
 
 // JSONParser parses JSON data into a tree structure
 type JSONParser struct {
-	// Configuration options could go here
+	// Lazy defers building child nodes until a node is first expanded
+	Lazy bool
+
+	// Lenient makes ParseJSONL skip lines that fail to parse instead of failing
+	Lenient bool
+
+	// Skipped holds why each line was skipped by the last lenient ParseJSONL
+	Skipped []error
+
+	// DuplicateKeys counts the keys the last Parse or ParseJSONL found
+	// repeated within one object. Every occurrence is kept in the tree.
+	DuplicateKeys int
 }
 
 // NewJSONParser creates a new JSON parser
@@ -17,62 +33,270 @@ func NewJSONParser() *JSONParser {
 	return &JSONParser{}
 }
 
-// Parse parses JSON data into a tree structure
+// Parse parses JSON data into a tree structure, preserving object key order
 func (p *JSONParser) Parse(data []byte) (*model.JSONNode, error) {
-	var v interface{}
-
 	// Parse JSON
-	err := json.Unmarshal(data, &v)
+	p.DuplicateKeys = 0
+	v, err := decodeOrdered(stripBOM(data), &p.DuplicateKeys)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
 	// Create the root node
-	rootNode := model.NewJSONNode("root", v, nil)
-	return rootNode, nil
+	return p.newRoot(v), nil
 }
 
-// ParseJSONL parses JSONL data (one JSON object per line)
-func (p *JSONParser) ParseJSONL(data []byte) ([]*model.JSONNode, error) {
-	var nodes []*model.JSONNode
+// ParseJSONL parses JSONL data (one JSON value per line) into a single tree.
+// The root is a synthetic array whose children are the per-line values,
+// keyed [0], [1], ... in order. Empty lines are skipped and don't consume an index.
+// In lenient mode malformed lines are skipped too and recorded in p.Skipped;
+// parsing only fails if no line could be parsed.
+func (p *JSONParser) ParseJSONL(data []byte) (*model.JSONNode, error) {
+	var values []interface{}
+	p.Skipped = nil
+	p.DuplicateKeys = 0
 
 	// Split by lines and parse each line separately
-	lines := splitLines(data)
+	lines := splitLines(stripBOM(data))
 	for i, line := range lines {
 		// Skip empty lines
-		if len(line) == 0 {
+		if len(bytes.TrimSpace(line)) == 0 {
 			continue
 		}
 
-		var v interface{}
-		err := json.Unmarshal(line, &v)
+		v, err := decodeOrdered(line, &p.DuplicateKeys)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse line %d: %w", i+1, err)
+			err = lineError(i, err)
+			if !p.Lenient {
+				return nil, err
+			}
+			p.Skipped = append(p.Skipped, err)
+			continue
 		}
 
-		// Create a node for this line
-		key := fmt.Sprintf("[%d]", i)
-		node := model.NewJSONNode(key, v, nil)
-		nodes = append(nodes, node)
+		values = append(values, v)
+	}
+	if len(values) == 0 && len(p.Skipped) > 0 {
+		return nil, p.Skipped[0]
+	}
+
+	// Build the synthetic array root and label each record by its position
+	rootNode := p.newRoot(values)
+	for i, child := range rootNode.Children {
+		child.Key = fmt.Sprintf("[%d]", i)
+	}
+
+	return rootNode, nil
+}
+
+// lineError places an error decoding the line at index i within the whole input
+func lineError(i int, err error) error {
+	var syntaxErr *JSONSyntaxError
+	if errors.As(err, &syntaxErr) {
+		syntaxErr.Line = i + 1
+		return fmt.Errorf("failed to parse JSONL: %w", err)
+	}
+	return fmt.Errorf("failed to parse line %d: %w", i+1, err)
+}
+
+// newRoot builds the root node for a parsed value. In lazy mode only the
+// root's direct children are created and the root starts expanded.
+func (p *JSONParser) newRoot(v interface{}) *model.JSONNode {
+	if !p.Lazy {
+		return model.NewJSONNode("root", v, nil)
 	}
 
-	return nodes, nil
+	rootNode := model.NewLazyJSONNode("root", v, nil)
+	rootNode.LoadChildren()
+	rootNode.Expanded = true
+	return rootNode
 }
 
-// Helper function to split data into lines
+// decodeOrdered decodes a single JSON document by walking the token stream,
+// so objects come back as model.OrderedObject in their original key order.
+// Repeated keys are all kept, unlike json.Unmarshal, and added to duplicates.
+func decodeOrdered(data []byte, duplicates *int) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// Keep numbers as written, so integers past 2^53 aren't rounded
+	dec.UseNumber()
+
+	v, err := decodeValue(dec, duplicates)
+	if err != nil {
+		return nil, locateJSONError(data, dec, err)
+	}
+
+	// Reject trailing content after the top-level value, like json.Unmarshal
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("invalid character after top-level value")
+		}
+		return nil, locateJSONError(data, dec, err)
+	}
+
+	return v, nil
+}
+
+// JSONSyntaxError is a JSON parse failure with the position it happened at
+type JSONSyntaxError struct {
+	Line    int    // 1-based line of the offending text
+	Column  int    // 1-based column, in characters
+	Snippet string // The offending line with a caret under the column
+	Err     error
+}
+
+// Error formats the position, the cause and the snippet
+func (e *JSONSyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v\n%s", e.Line, e.Column, e.Err, e.Snippet)
+}
+
+// Unwrap returns the underlying decoding error
+func (e *JSONSyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// jsonSnippetRadius is how many characters of context are shown either side of an error
+const jsonSnippetRadius = 30
+
+// locateJSONError wraps a decoding error with the line, column and text where it happened
+func locateJSONError(data []byte, dec *json.Decoder, err error) error {
+	// The decoder reports how far it had read; the offending byte is the last one
+	offset := dec.InputOffset()
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset - 1
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset - 1
+	case errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF):
+		offset = int64(len(data))
+		err = fmt.Errorf("unexpected end of JSON input")
+	}
+	if offset < 0 {
+		offset = 0
+	} else if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
+	// Find the line holding the offset
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+	lineEnd := len(data)
+	if idx := bytes.IndexByte(data[offset:], '\n'); idx >= 0 {
+		lineEnd = int(offset) + idx
+	}
+	line := []rune(strings.TrimRight(string(data[lineStart:lineEnd]), "\r"))
+	column := utf8.RuneCount(data[lineStart:offset])
+
+	// Show a window of the line around the column
+	from := max(0, column-jsonSnippetRadius)
+	to := min(len(line), column+jsonSnippetRadius)
+	prefix, suffix := "", ""
+	if from > 0 {
+		prefix = "..."
+	}
+	if to < len(line) {
+		suffix = "..."
+	}
+	snippet := "  " + prefix + string(line[from:to]) + suffix + "\n" +
+		"  " + strings.Repeat(" ", len(prefix)+column-from) + "^"
+
+	return &JSONSyntaxError{
+		Line:    bytes.Count(data[:lineStart], []byte("\n")) + 1,
+		Column:  column + 1,
+		Snippet: snippet,
+		Err:     err,
+	}
+}
+
+// decodeValue reads the next complete value from the decoder, counting the
+// keys repeated within an object in duplicates
+func decodeValue(dec *json.Decoder, duplicates *int) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		// Scalars: string, json.Number, bool or nil
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := model.OrderedObject{}
+		seen := make(map[string]struct{})
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyTok.(string)
+			if _, ok := seen[key]; ok {
+				*duplicates++
+			}
+			seen[key] = struct{}{}
+
+			value, err := decodeValue(dec, duplicates)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, model.ObjectEntry{Key: key, Value: value})
+		}
+		// Consume the closing brace
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+
+	case '[':
+		arr := []interface{}{}
+		for dec.More() {
+			value, err := decodeValue(dec, duplicates)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		// Consume the closing bracket
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+
+	default:
+		return nil, fmt.Errorf("unexpected delimiter %q", delim)
+	}
+}
+
+// splitLines splits data into its non-empty lines, ended by LF or CRLF
 func splitLines(data []byte) [][]byte {
 	var lines [][]byte
 	start := 0
 	for i := 0; i < len(data); i++ {
 		if data[i] == '\n' {
-			if i > start {
-				lines = append(lines, data[start:i])
+			if line := trimCR(data[start:i]); len(line) > 0 {
+				lines = append(lines, line)
 			}
 			start = i + 1
 		}
 	}
-	if start < len(data) {
-		lines = append(lines, data[start:])
+	if line := trimCR(data[start:]); len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitAllLines splits data into lines ended by LF or CRLF, keeping blank lines
+func splitAllLines(data []byte) [][]byte {
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		lines[i] = trimCR(line)
 	}
 	return lines
 }
+
+// trimCR removes the carriage return a CRLF line ending leaves on a line
+func trimCR(line []byte) []byte {
+	return bytes.TrimSuffix(line, []byte("\r"))
+}
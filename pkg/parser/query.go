@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tablux/pkg/model"
+)
+
+// querySegment is one step of a path expression
+type querySegment struct {
+	key      string // Object key to descend into
+	index    int    // Array index to descend into, when isIndex is set
+	isIndex  bool
+	wildcard bool // [] selects every child
+}
+
+// Query narrows a parsed tree to the nodes matched by a jq-style path
+// expression such as .users[2].name, .config["weird.key"] or .items[].id.
+// The result is a new tree rooted at the matched value; when the expression
+// contains the [] wildcard the root is an array holding every match.
+func (p *JSONParser) Query(root *model.JSONNode, expr string) (*model.JSONNode, error) {
+	segments, err := parseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []*model.JSONNode{root}
+	wildcard := false
+	for _, segment := range segments {
+		var next []*model.JSONNode
+		for _, node := range matches {
+			children, err := segment.apply(node)
+			if err != nil {
+				return nil, fmt.Errorf("query %s: %w", expr, err)
+			}
+			next = append(next, children...)
+		}
+		matches = next
+		wildcard = wildcard || segment.wildcard
+	}
+
+	if !wildcard {
+		return p.newRoot(matches[0].RawValue()), nil
+	}
+	values := make([]interface{}, 0, len(matches))
+	for _, match := range matches {
+		values = append(values, match.RawValue())
+	}
+	return p.newRoot(values), nil
+}
+
+// FindPath returns the node of root at a path expression without wildcards,
+// such as .users[2].name: the format of JSONNode.Path. Unlike Query it
+// points into root's own tree rather than building a new one.
+func FindPath(root *model.JSONNode, expr string) (*model.JSONNode, error) {
+	segments, err := parseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	node := root
+	for _, segment := range segments {
+		if segment.wildcard {
+			return nil, fmt.Errorf("path %s: [] selects more than one node", expr)
+		}
+		children, err := segment.apply(node)
+		if err != nil {
+			return nil, fmt.Errorf("path %s: %w", expr, err)
+		}
+		node = children[0]
+	}
+	return node, nil
+}
+
+// apply returns the children of node selected by the segment
+func (s querySegment) apply(node *model.JSONNode) ([]*model.JSONNode, error) {
+	node.LoadChildren()
+
+	switch {
+	case s.wildcard:
+		if node.Type != model.NodeObject && node.Type != model.NodeArray {
+			return nil, fmt.Errorf("cannot iterate over %s at %s", node.TypeString(), node.DisplayPath())
+		}
+		return node.Children, nil
+
+	case s.isIndex:
+		if node.Type != model.NodeArray {
+			return nil, fmt.Errorf("cannot index %s at %s", node.TypeString(), node.DisplayPath())
+		}
+		index := s.index
+		if index < 0 {
+			// Negative indices count from the end, like jq
+			index += len(node.Children)
+		}
+		if index < 0 || index >= len(node.Children) {
+			return nil, fmt.Errorf("index %d out of range at %s, which has %d elements",
+				s.index, node.DisplayPath(), len(node.Children))
+		}
+		return node.Children[index : index+1], nil
+
+	default:
+		if node.Type != model.NodeObject {
+			return nil, fmt.Errorf("cannot look up key %q in %s at %s", s.key, node.TypeString(), node.DisplayPath())
+		}
+		for _, child := range node.Children {
+			if child.Key == s.key {
+				return []*model.JSONNode{child}, nil
+			}
+		}
+		return nil, fmt.Errorf("key %q not found at %s", s.key, node.DisplayPath())
+	}
+}
+
+// parseQuery splits a path expression into segments. Expressions start with
+// a dot; "." alone selects the whole document.
+func parseQuery(expr string) ([]querySegment, error) {
+	rest := strings.TrimSpace(expr)
+	if !strings.HasPrefix(rest, ".") {
+		return nil, fmt.Errorf("invalid query %q: must start with '.'", expr)
+	}
+
+	var segments []querySegment
+	for first := true; rest != ""; first = false {
+		switch {
+		case rest[0] == '[':
+			segment, n, err := parseBracket(rest)
+			if err != nil {
+				return nil, fmt.Errorf("invalid query %q: %w", expr, err)
+			}
+			segments = append(segments, segment)
+			rest = rest[n:]
+
+		case rest[0] == '.':
+			rest = rest[1:]
+			// A dot may lead into a bracket, e.g. .[0], or end a bare "."
+			if rest == "" && first {
+				return segments, nil
+			}
+			if rest != "" && rest[0] == '[' {
+				continue
+			}
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid query %q: empty key", expr)
+			}
+			segments = append(segments, querySegment{key: rest[:end]})
+			rest = rest[end:]
+
+		default:
+			return nil, fmt.Errorf("invalid query %q: unexpected %q", expr, rest[0])
+		}
+	}
+	return segments, nil
+}
+
+// parseBracket parses a bracketed segment at the start of s, i.e. [], [N]
+// or ["key"], returning it with the number of bytes it spans
+func parseBracket(s string) (querySegment, int, error) {
+	// Quoted keys may themselves contain ']'
+	if strings.HasPrefix(s, `["`) {
+		quoted, err := strconv.QuotedPrefix(s[1:])
+		if err != nil || !strings.HasPrefix(s[1+len(quoted):], "]") {
+			return querySegment{}, 0, fmt.Errorf("unterminated key in %s", s)
+		}
+		key, _ := strconv.Unquote(quoted)
+		return querySegment{key: key}, len(quoted) + 2, nil
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return querySegment{}, 0, fmt.Errorf("missing ']' in %s", s)
+	}
+	inner := strings.TrimSpace(s[1:end])
+	if inner == "" {
+		return querySegment{wildcard: true}, end + 1, nil
+	}
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return querySegment{}, 0, fmt.Errorf("invalid index %q", inner)
+	}
+	return querySegment{index: index, isIndex: true}, end + 1, nil
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"tablux/pkg/model"
+)
+
+// TOMLParser parses TOML data into the same tree structure as JSON
+type TOMLParser struct {
+	// Lazy defers building child nodes until a node is first expanded
+	Lazy bool
+}
+
+// NewTOMLParser creates a new TOML parser
+func NewTOMLParser() *TOMLParser {
+	return &TOMLParser{}
+}
+
+// Parse parses TOML data into a tree structure. Tables become objects and
+// arrays of tables become arrays of objects, with keys in document order.
+func (p *TOMLParser) Parse(data []byte) (*model.JSONNode, error) {
+	var v map[string]interface{}
+	md, err := toml.Decode(string(data), &v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TOML: %w", err)
+	}
+
+	// Record where each key path first appears so tables keep their order
+	order := make(map[string]int)
+	for i, key := range md.Keys() {
+		path := key.String()
+		if _, seen := order[path]; !seen {
+			order[path] = i
+		}
+	}
+
+	return (&JSONParser{Lazy: p.Lazy}).newRoot(tomlValue(v, "", order)), nil
+}
+
+// tomlValue converts a decoded TOML value into the interface{} shape used by
+// the JSON model, with tables as model.OrderedObject
+func tomlValue(value interface{}, path string, order map[string]int) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return tomlTable(v, path, order)
+	case []map[string]interface{}:
+		arr := make([]interface{}, 0, len(v))
+		for _, table := range v {
+			arr = append(arr, tomlTable(table, path, order))
+		}
+		return arr
+	case []interface{}:
+		arr := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			arr = append(arr, tomlValue(item, path, order))
+		}
+		return arr
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		// Local dates and times
+		return v.String()
+	default:
+		return v
+	}
+}
+
+// tomlTable converts a table, ordering keys by their position in the document
+func tomlTable(table map[string]interface{}, path string, order map[string]int) model.OrderedObject {
+	keys := make([]string, 0, len(table))
+	for k := range table {
+		keys = append(keys, k)
+	}
+
+	position := func(k string) int {
+		if i, ok := order[joinTOMLKey(path, k)]; ok {
+			return i
+		}
+		return len(order)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		pi, pj := position(keys[i]), position(keys[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return keys[i] < keys[j]
+	})
+
+	obj := make(model.OrderedObject, 0, len(keys))
+	for _, k := range keys {
+		childPath := joinTOMLKey(path, k)
+		obj = append(obj, model.ObjectEntry{Key: k, Value: tomlValue(table[k], childPath, order)})
+	}
+	return obj
+}
+
+// joinTOMLKey joins a key onto a table path the way toml.Key.String does
+func joinTOMLKey(path, key string) string {
+	k := toml.Key{key}.String()
+	if path == "" {
+		return k
+	}
+	return path + "." + k
+}
+
+// looksLikeTOML reports whether data has both [section] headers and
+// key = value lines, and decodes as TOML
+func looksLikeTOML(data []byte) bool {
+	hasSection, hasAssignment := false, false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			hasSection = true
+		case strings.Contains(line, "="):
+			hasAssignment = true
+		}
+	}
+
+	if !hasSection || !hasAssignment {
+		return false
+	}
+
+	var v map[string]interface{}
+	_, err := toml.Decode(string(data), &v)
+	return err == nil
+}
@@ -0,0 +1,197 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+	"tablux/pkg/model"
+)
+
+// YAMLParser parses YAML data into the same tree structure as JSON
+type YAMLParser struct {
+	// Lazy defers building child nodes until a node is first expanded
+	Lazy bool
+}
+
+// NewYAMLParser creates a new YAML parser
+func NewYAMLParser() *YAMLParser {
+	return &YAMLParser{}
+}
+
+// Parse parses the first YAML document into a tree structure.
+// Mapping key order is preserved and anchors/aliases are resolved to their values.
+func (p *YAMLParser) Parse(data []byte) (*model.JSONNode, error) {
+	var doc yaml.Node
+	err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc)
+	if err == io.EOF {
+		// An empty document is a null value
+		return (&JSONParser{Lazy: p.Lazy}).newRoot(nil), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	v, err := yamlNodeValue(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	// Build the tree the same way JSON does
+	return (&JSONParser{Lazy: p.Lazy}).newRoot(v), nil
+}
+
+// yamlNodeValue converts a YAML node into the interface{} shape used by the
+// JSON model, with mappings as model.OrderedObject
+func yamlNodeValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlNodeValue(node.Content[0])
+
+	case yaml.AliasNode:
+		return yamlNodeValue(node.Alias)
+
+	case yaml.MappingNode:
+		obj := newObjectBuilder(len(node.Content) / 2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+
+			// Merge keys (<<: *anchor) inline the referenced mapping(s)
+			if keyNode.Tag == "!!merge" {
+				merged, err := yamlMergeEntries(valueNode)
+				if err != nil {
+					return nil, err
+				}
+				obj.appendMissing(merged)
+				continue
+			}
+
+			value, err := yamlNodeValue(valueNode)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(keyNode.Value, value)
+		}
+		return obj.entries, nil
+
+	case yaml.SequenceNode:
+		arr := []interface{}{}
+		for _, item := range node.Content {
+			value, err := yamlNodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		return arr, nil
+
+	case yaml.ScalarNode:
+		return yamlScalarValue(node)
+
+	default:
+		return nil, fmt.Errorf("unsupported YAML node at line %d", node.Line)
+	}
+}
+
+// yamlScalarValue decodes a scalar into a string, number, boolean or nil
+func yamlScalarValue(node *yaml.Node) (interface{}, error) {
+	var v interface{}
+	if err := node.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	switch val := v.(type) {
+	case string, bool, int, float64, nil:
+		return val, nil
+	case int64:
+		return val, nil
+	case uint64:
+		// Too large for int64; kept as written rather than rounded to a float
+		return json.Number(strconv.FormatUint(val, 10)), nil
+	default:
+		// Timestamps and other tagged scalars are shown as written
+		return node.Value, nil
+	}
+}
+
+// yamlMergeEntries resolves the value of a merge key into object entries
+func yamlMergeEntries(node *yaml.Node) (model.OrderedObject, error) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	// A sequence merges several mappings, earlier ones taking precedence
+	if node.Kind == yaml.SequenceNode {
+		merged := newObjectBuilder(0)
+		for _, item := range node.Content {
+			entries, err := yamlMergeEntries(item)
+			if err != nil {
+				return nil, err
+			}
+			merged.appendMissing(entries)
+		}
+		return merged.entries, nil
+	}
+
+	value, err := yamlNodeValue(node)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := value.(model.OrderedObject)
+	if !ok {
+		return nil, fmt.Errorf("merge key at line %d must reference a mapping", node.Line)
+	}
+	return obj, nil
+}
+
+// objectBuilder assembles the entries of a mapping, indexing them by key so
+// merge keys are resolved in time linear in the number of keys
+type objectBuilder struct {
+	entries model.OrderedObject
+	index   map[string]int // Position of each key in entries
+}
+
+// newObjectBuilder returns an empty builder with room for size keys
+func newObjectBuilder(size int) *objectBuilder {
+	return &objectBuilder{
+		entries: make(model.OrderedObject, 0, size),
+		index:   make(map[string]int, size),
+	}
+}
+
+// set sets a key, replacing a value inherited through a merge key
+func (b *objectBuilder) set(key string, value interface{}) {
+	if i, ok := b.index[key]; ok {
+		b.entries[i].Value = value
+		return
+	}
+	b.index[key] = len(b.entries)
+	b.entries = append(b.entries, model.ObjectEntry{Key: key, Value: value})
+}
+
+// appendMissing appends entries whose keys aren't already present
+func (b *objectBuilder) appendMissing(entries model.OrderedObject) {
+	for _, entry := range entries {
+		if _, ok := b.index[entry.Key]; !ok {
+			b.index[entry.Key] = len(b.entries)
+			b.entries = append(b.entries, entry)
+		}
+	}
+}
+
+// looksLikeYAML reports whether data parses as a YAML mapping or sequence.
+// Plain text parses as a scalar, so only structured documents are accepted.
+func looksLikeYAML(data []byte) bool {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return false
+	}
+	kind := doc.Content[0].Kind
+	return kind == yaml.MappingNode || kind == yaml.SequenceNode
+}
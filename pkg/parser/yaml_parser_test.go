@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	"tablux/pkg/model"
+)
+
+// yamlValue decodes a YAML document into the shape the tree is built from
+func yamlValue(t testing.TB, text string) interface{} {
+	t.Helper()
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &doc); err != nil {
+		t.Fatal(err)
+	}
+	value, err := yamlNodeValue(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func TestYAMLMergeKeys(t *testing.T) {
+	root := yamlValue(t, `
+base: &base {a: 1, b: 2}
+more: &more {b: 20, c: 3}
+obj:
+  <<: [*base, *more]
+  a: 10
+  d: 4
+`).(model.OrderedObject)
+
+	// Earlier merged mappings take precedence, and the mapping's own keys
+	// over both, while keeping the position the key was first merged at
+	got := fmt.Sprint(root[2].Value)
+	if want := "[{a 10} {b 2} {c 3} {d 4}]"; got != want {
+		t.Errorf("got merged entries %s, want %s", got, want)
+	}
+}
+
+func BenchmarkYAMLWideMapping(b *testing.B) {
+	var text strings.Builder
+	for i := range 100000 {
+		fmt.Fprintf(&text, "key%d: %d\n", i, i)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(text.String()), &doc); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for range b.N {
+		if _, err := yamlNodeValue(&doc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
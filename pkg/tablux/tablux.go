@@ -0,0 +1,172 @@
+// Package tablux parses and renders JSON, JSONL, CSV, TSV, YAML and TOML
+// data as plain strings, without running the interactive program. It is the
+// stable entry point for embedding tablux's rendering in other tools:
+//
+//	out, err := tablux.RenderString(data, "", 100, 30)
+//
+// Formats are named by the parser.Type* constants ("json", "csv", ...); an
+// empty format detects it from the content. The interactive program parses
+// through Parse too. The functions below, together with the exported viewer
+// types in pkg/ui and the tree and table models in pkg/model and pkg/parser,
+// make up the API that is kept stable.
+package tablux
+
+import (
+	"bytes"
+	"fmt"
+
+	"tablux/pkg/model"
+	"tablux/pkg/parser"
+	"tablux/pkg/ui"
+)
+
+// Detect returns the format of data as one of the parser.Type* names, or
+// parser.TypeUnknown when it isn't recognized
+func Detect(data []byte) string {
+	return parser.DetectFileType(data)
+}
+
+// IsTree reports whether a format is shown as a tree rather than a table
+func IsTree(format string) bool {
+	switch format {
+	case parser.TypeJSON, parser.TypeJSONL, parser.TypeYAML, parser.TypeTOML:
+		return true
+	}
+	return false
+}
+
+// Options tunes how Parse reads data. Start from DefaultOptions, as the zero
+// value turns comment lines in tables into rows.
+type Options struct {
+	Lazy      bool              // Build tree nodes only when they are first expanded
+	Lenient   bool              // Skip malformed JSONL lines rather than failing
+	Comment   rune              // Table lines starting with it are skipped; 0 keeps every line
+	TrimSpace bool              // Strip the whitespace around every table field
+	Header    parser.HeaderMode // Whether the first table row names the columns
+}
+
+// DefaultOptions returns the options the viewer parses with unless told otherwise
+func DefaultOptions() Options {
+	return Options{Comment: parser.DefaultComment, Header: parser.HeaderAlways}
+}
+
+// Document is parsed data, either a tree or a table, along with what the
+// parser noticed on the way
+type Document struct {
+	Format        string          // One of the parser.Type* names
+	Tree          *model.JSONNode // The tree of a JSON, JSONL, YAML or TOML document
+	Table         *parser.CSVData // The table of a CSV or TSV document
+	Skipped       []error         // Why JSONL lines were left out by a lenient parse
+	DuplicateKeys int             // How many keys JSON objects repeated
+}
+
+// Parse parses data of any supported format. An empty format detects it,
+// and for tables the delimiter, from the content.
+func Parse(data []byte, format string, opts Options) (*Document, error) {
+	var delimiter rune
+	if format == "" {
+		format, delimiter = parser.DetectFileTypeWithDelimiter(data)
+	}
+	doc := &Document{Format: format}
+
+	var err error
+	switch format {
+	case parser.TypeJSON, parser.TypeJSONL:
+		jsonParser := parser.NewJSONParser()
+		jsonParser.Lazy = opts.Lazy
+		jsonParser.Lenient = opts.Lenient
+		if format == parser.TypeJSON {
+			doc.Tree, err = jsonParser.Parse(data)
+		} else {
+			doc.Tree, err = jsonParser.ParseJSONL(data)
+		}
+		doc.Skipped = jsonParser.Skipped
+		doc.DuplicateKeys = jsonParser.DuplicateKeys
+	case parser.TypeYAML:
+		yamlParser := parser.NewYAMLParser()
+		yamlParser.Lazy = opts.Lazy
+		doc.Tree, err = yamlParser.Parse(data)
+	case parser.TypeTOML:
+		tomlParser := parser.NewTOMLParser()
+		tomlParser.Lazy = opts.Lazy
+		doc.Tree, err = tomlParser.Parse(data)
+	case parser.TypeCSV, parser.TypeTSV:
+		csvParser := parser.NewCSVParser()
+		switch {
+		case format == parser.TypeTSV:
+			csvParser.Comma = '\t'
+		case delimiter != 0:
+			csvParser.Comma = delimiter
+		}
+		csvParser.Comment = opts.Comment
+		csvParser.TrimSpace = opts.TrimSpace
+		csvParser.Header = opts.Header
+		doc.Table, err = csvParser.Parse(data)
+	default:
+		return nil, fmt.Errorf("unsupported file type")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// ParseTree parses JSON, JSONL, YAML or TOML data into a tree. An empty
+// format detects it from the content.
+func ParseTree(data []byte, format string) (*model.JSONNode, error) {
+	if format == "" {
+		format = Detect(data)
+	}
+	if !IsTree(format) {
+		return nil, fmt.Errorf("unsupported tree format %q", format)
+	}
+
+	doc, err := Parse(data, format, DefaultOptions())
+	if err != nil {
+		return nil, err
+	}
+	return doc.Tree, nil
+}
+
+// ParseTable parses CSV or TSV data into a table. An empty format detects
+// it, and the delimiter, from the content.
+func ParseTable(data []byte, format string) (*parser.CSVData, error) {
+	if format != "" && format != parser.TypeCSV && format != parser.TypeTSV {
+		return nil, fmt.Errorf("unsupported table format %q", format)
+	}
+
+	doc, err := Parse(data, format, DefaultOptions())
+	if err != nil {
+		return nil, err
+	}
+	if doc.Table == nil {
+		return nil, fmt.Errorf("unsupported table format %q", doc.Format)
+	}
+	return doc.Table, nil
+}
+
+// RenderString parses data and renders it the way the viewer first shows
+// it: trees fully expanded, tables with their border and header. The output
+// holds at most height lines of content and is clipped to width cells.
+// Blank input renders as an empty string.
+func RenderString(data []byte, format string, width, height int) (string, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return "", nil
+	}
+
+	doc, err := Parse(data, format, DefaultOptions())
+	if err != nil {
+		return "", err
+	}
+
+	if doc.Tree != nil {
+		viewer := ui.NewJSONViewer(doc.Tree)
+		viewer.SetViewportHeight(height)
+		viewer.SetViewportWidth(width)
+		return viewer.Render(), nil
+	}
+
+	viewer := ui.NewCSVViewer(doc.Table)
+	viewer.SetViewport(width, height)
+	return viewer.Render(), nil
+}
@@ -0,0 +1,45 @@
+package tablux
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestRenderStringDetectsDelimiter(t *testing.T) {
+	out, err := RenderString([]byte("a;b;c\n1;2;3\n4;5;6"), "", 80, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := ansi.Strip(out)
+	if strings.Contains(plain, "a;b;c") || strings.Contains(plain, "1;2;3") {
+		t.Fatalf("semicolons were not read as the delimiter:\n%s", plain)
+	}
+	if header := strings.Fields(strings.Split(plain, "\n")[1]); strings.Join(header, " ") != "│ a b c │" {
+		t.Fatalf("got header %q, want columns a, b and c", header)
+	}
+}
+
+func TestRenderStringClipsTrees(t *testing.T) {
+	const width = 30
+	out, err := RenderString([]byte(`{"description": "`+strings.Repeat("long ", 20)+`"}`), "", width, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if w := ansi.StringWidth(line); w > width {
+			t.Errorf("line %q is %d cells wide, more than %d", ansi.Strip(line), w, width)
+		}
+	}
+}
+
+func TestParseTableDetectsDelimiter(t *testing.T) {
+	table, err := ParseTable([]byte("a;b\n1;2\n3;4"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Headers) != 2 || table.Headers[0] != "a" || table.Headers[1] != "b" {
+		t.Fatalf("got headers %q, want a and b", table.Headers)
+	}
+}
@@ -1,46 +1,117 @@
 package ui
 
 import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"tablux/pkg/model"
 	"tablux/pkg/parser"
 )
 
 var (
-	// CSV viewer styles (using theme constants)
-	// Using theme-defined styles directly
-	headerStyle       = HeaderStyle
-	cellStyle         = CellStyle
-	selectedRowStyle  = SelectedRowStyle
-	selectedColStyle  = SelectedColStyle
-	selectedCellStyle = SelectedCellStyle
+	// CSV viewer styles, assigned from the theme by applyCSVStyles
+	headerStyle       lipgloss.Style
+	cellStyle         lipgloss.Style
+	stripedCellStyle  lipgloss.Style
+	selectedRowStyle  lipgloss.Style
+	selectedColStyle  lipgloss.Style
+	selectedCellStyle lipgloss.Style
 
 	// Collapsed/hidden column style
-	collapsedColHeaderStyle = CollapsedHeaderStyle
-	collapsedColStyle       = CollapsedCellStyle
+	collapsedColHeaderStyle lipgloss.Style
+	collapsedColStyle       lipgloss.Style
 
-	// Table styles
-	separatorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(MutedTextColor))
+	// Row number gutter style
+	rowNumberStyle lipgloss.Style
 
-	tableStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color(MutedTextColor))
+	// Table styles
+	separatorStyle lipgloss.Style
+	tableStyle     lipgloss.Style
 
 	// Column separators
 	columnSeparator = " "
 
-	// Column sorting indicators using theme constants
-	sortAscIndicator  = SortAscIndicator
-	sortDescIndicator = SortDescIndicator
+	// Lines a rendered table spends on its top/bottom border and header row
+	tableChromeLines = 3
+
+	// Most lines of cell text shown in the detail pane
+	detailMaxLines = 10
+
+	// Most values listed by the distinct values pane, and the widest its
+	// labels and bars get
+	distinctMaxValues  = 10
+	distinctLabelWidth = 24
+	distinctBarWidth   = 40
+
+	// Column sorting indicators, assigned from the theme by applyCSVStyles
+	sortAscIndicator  string
+	sortDescIndicator string
 
 	// Defaults using theme constants
 	defaultCellPadding    = DefaultCellPadding
 	defaultColumnMaxWidth = DefaultColumnMaxWidth
 	collapsedColumnWidth  = CollapsedColumnWidth
 
-	// Collapsed column indicator
-	collapsedIndicator = CollapsedColumn
+	// Collapsed column indicator, assigned from the theme by applyCSVStyles
+	collapsedIndicator string
+)
+
+// applyCSVStyles refreshes the CSV viewer styles from the theme
+func applyCSVStyles() {
+	headerStyle = HeaderStyle
+	cellStyle = CellStyle
+	stripedCellStyle = StripedCellStyle
+	selectedRowStyle = SelectedRowStyle
+	selectedColStyle = SelectedColStyle
+	selectedCellStyle = SelectedCellStyle
+
+	collapsedColHeaderStyle = CollapsedHeaderStyle
+	collapsedColStyle = CollapsedCellStyle
+
+	rowNumberStyle = RowNumberStyle
+
+	separatorStyle = lipgloss.NewStyle().Foreground(themeColor(activeTheme.MutedText))
+	border := lipgloss.NormalBorder()
+	if activeTheme.ASCII {
+		border = lipgloss.ASCIIBorder()
+	}
+	tableStyle = lipgloss.NewStyle().
+		BorderStyle(border).
+		BorderForeground(themeColor(activeTheme.MutedText))
+
+	sortAscIndicator = activeTheme.SortAsc
+	sortDescIndicator = activeTheme.SortDesc
+	collapsedIndicator = activeTheme.CollapsedColumn
+}
+
+// paneKind identifies the pane shown below the table
+type paneKind int
+
+const (
+	paneNone paneKind = iota
+	paneCellDetail
+	paneColumnStats
+	paneDistinctValues
+)
+
+// DuplicateMode restricts the displayed rows by whether their key, a cell
+// or the whole row, repeats in another row
+type DuplicateMode int
+
+const (
+	// DuplicatesOff shows rows regardless of repeats
+	DuplicatesOff DuplicateMode = iota
+	// DuplicatesOnly shows just the rows whose key another row shares
+	DuplicatesOnly
+	// UniqueOnly shows each key's first row and hides the repeats
+	UniqueOnly
 )
 
 // CSVViewer displays a CSV table
@@ -48,12 +119,59 @@ type CSVViewer struct {
 	data           *parser.CSVData
 	cursorRow      int
 	cursorCol      int
-	viewportX      int
+	viewportX      int // First column shown when the table is wider than the viewport
 	viewportY      int
 	viewportWidth  int
 	viewportHeight int
 	columnMaxWidth int   // Max width of a column before truncation
 	columnWidths   []int // Pre-calculated widths for columns
+	contentWidths  []int // Widths the columns' content needs, before capping or fitting
+	fitColumns     bool  // Whether columns are sized to fill the viewport width
+	numberFormat   model.NumberFormat
+	filterQuery    string
+	filterColumn   int            // Column a "column:value" query is scoped to, or -1 for every column
+	filterText     string         // The query's value to match, without any column prefix
+	filterRegexp   *regexp.Regexp // The compiled filterText when it is a regular expression
+	displayRows    []int          // Indices into data.Rows that are currently displayed
+
+	// Rows are also restricted by how the cell at duplicateColumn, or the
+	// whole row when it is negative, repeats
+	duplicateMode   DuplicateMode
+	duplicateColumn int
+
+	// Column visibility saved by IsolateColumn, restored by the next call
+	isolatedVisibility []bool
+
+	// Which pane, if any, is shown below the table
+	pane paneKind
+
+	// Whether the table is drawn transposed, each column as a line. The
+	// viewport then scrolls through columns with viewportX and through rows
+	// with viewportY, the same fields keeping the same meaning.
+	transposed bool
+
+	// Statistics of statsColumn over the displayed rows, computed on first
+	// use and dropped when the displayed rows change
+	stats       *parser.ColumnStats
+	statsColumn int
+
+	// Values of distinctColumn by frequency over the displayed rows, cached
+	// the same way
+	distinct       []parser.ValueCount
+	distinctColumn int
+
+	// ShowRowNumbers prepends a gutter with each row's original 1-based position
+	ShowRowNumbers bool
+
+	// ShowRuler adds a line above the header with each column's 1-based index
+	ShowRuler bool
+
+	// WrapNavigation makes moving past the last row or column continue from the first, and back
+	WrapNavigation bool
+
+	// FrozenColumns is how many leading columns stay on screen, ahead of the
+	// columns scrolled into view, when the table is scrolled sideways
+	FrozenColumns int
 }
 
 // NewCSVViewer creates a new CSV viewer
@@ -65,44 +183,82 @@ func NewCSVViewer(data *parser.CSVData) *CSVViewer {
 		viewportX:      0,
 		viewportY:      0,
 		columnMaxWidth: defaultColumnMaxWidth,
+		filterColumn:   -1,
 	}
 
 	// Pre-calculate column widths
 	viewer.calculateColumnWidths()
+	viewer.applyFilter()
 
 	return viewer
 }
 
-// calculateColumnWidths pre-calculates optimal widths for all columns
+// Data returns the CSV data being displayed
+func (v *CSVViewer) Data() *parser.CSVData {
+	return v.data
+}
+
+// RestoreState carries the cursor, column visibility, filter, row number
+// setting and transposition over from a viewer of an earlier version of the
+// same file. Column visibility is only kept when the number of columns
+// hasn't changed.
+func (v *CSVViewer) RestoreState(prev *CSVViewer) {
+	if len(prev.data.ColumnVisibility) == len(v.data.ColumnVisibility) {
+		copy(v.data.ColumnVisibility, prev.data.ColumnVisibility)
+	}
+	v.ShowRowNumbers = prev.ShowRowNumbers
+	v.ShowRuler = prev.ShowRuler
+	v.FrozenColumns = prev.FrozenColumns
+	v.fitColumns = prev.fitColumns
+	v.transposed = prev.transposed
+	v.updateColumnWidths()
+	// The scoped column is looked up again in case the columns moved, which
+	// changes the text a regular expression is compiled from. Should that
+	// text not compile any more, the query filters as plain text.
+	if prev.filterRegexp == nil || v.setFilter(prev.filterQuery, true) != nil {
+		v.setFilter(prev.filterQuery, false)
+	}
+	v.duplicateMode, v.duplicateColumn = prev.duplicateMode, prev.duplicateColumn
+	v.applyFilter()
+
+	v.cursorRow = min(prev.cursorRow, max(len(v.displayRows)-1, 0))
+	v.cursorCol = min(prev.cursorCol, max(len(v.data.Headers)-1, 0))
+	v.viewportX = prev.viewportX
+	v.viewportY = prev.viewportY
+	v.ensureCursorVisible()
+}
+
+// calculateColumnWidths measures the content of every column and lays the
+// columns out from it
 func (v *CSVViewer) calculateColumnWidths() {
-	colCount := len(v.data.Headers)
-	v.columnWidths = make([]int, colCount)
+	// Cells are measured in parallel, so infer the column types displayCell
+	// reads beforehand rather than racing to cache them
+	v.data.InferColumnTypes()
+	v.contentWidths = parser.MaxCellWidths(v.data.Rows, len(v.data.Headers), func(col int, cell string) int {
+		return ansi.StringWidth(v.displayCell(col, cell)) + 2 // Add padding
+	})
 
-	// Initialize with header widths
+	// Headers need room for padding and a sort indicator too
 	for i, header := range v.data.Headers {
-		// Add space for sort indicators
-		width := len(header) + 4 // Add padding and space for sort indicators
-		v.columnWidths[i] = width
-	}
-
-	// Update with data cell widths if needed
-	for _, row := range v.data.Rows {
-		for i, cell := range row {
-			if i < colCount {
-				cellWidth := len(cell) + 2
-				if cellWidth > v.columnWidths[i] {
-					v.columnWidths[i] = cellWidth
-				}
-			}
-		}
+		v.contentWidths[i] = max(v.contentWidths[i], ansi.StringWidth(header)+4)
 	}
 
+	v.updateColumnWidths()
+}
+
+// updateColumnWidths derives the column widths from the content widths:
+// capped at columnMaxWidth, then fitted to the viewport in fit mode
+func (v *CSVViewer) updateColumnWidths() {
+	v.columnWidths = make([]int, len(v.contentWidths))
+
 	// Cap all widths to maximum and ensure minimum width
-	for i, width := range v.columnWidths {
+	for i, width := range v.contentWidths {
 		if width > v.columnMaxWidth {
 			v.columnWidths[i] = v.columnMaxWidth
-		} else if width < 10 {
-			v.columnWidths[i] = 10
+		} else if width < MinColumnMaxWidth {
+			v.columnWidths[i] = MinColumnMaxWidth
+		} else {
+			v.columnWidths[i] = width
 		}
 
 		// Ensure even widths for better alignment
@@ -110,106 +266,1108 @@ func (v *CSVViewer) calculateColumnWidths() {
 			v.columnWidths[i]++
 		}
 	}
+
+	if v.fitColumns {
+		v.recomputeFitWidths()
+	}
+	v.ensureCursorVisible()
 }
 
-// SetViewport sets the viewport dimensions
+// recomputeFitWidths shares the viewport width out among the visible
+// columns in proportion to their content widths, so the table fills the
+// screen exactly and long cells are truncated. Columns whose share would be
+// narrower than MinFitColumnWidth get that width instead. When even that is
+// too wide for the viewport the capped widths are kept, and the table
+// scrolls sideways.
+func (v *CSVViewer) recomputeFitWidths() {
+	area := v.columnAreaWidth()
+	if area <= 0 {
+		return
+	}
+
+	var visible []int
+	for i := range v.data.Headers {
+		if v.data.ColumnVisibility[i] {
+			visible = append(visible, i)
+		} else {
+			area -= collapsedColumnWidth
+		}
+	}
+	if len(visible) == 0 || area < len(visible)*MinFitColumnWidth {
+		return
+	}
+
+	// Pin columns to the minimum until every other share clears it
+	atMinimum := make([]bool, len(v.data.Headers))
+	var remaining, total int
+	for changed := true; changed; {
+		changed = false
+		remaining, total = area, 0
+		for _, i := range visible {
+			if atMinimum[i] {
+				remaining -= MinFitColumnWidth
+			} else {
+				total += v.contentWidths[i]
+			}
+		}
+		for _, i := range visible {
+			if !atMinimum[i] && v.contentWidths[i]*remaining/total < MinFitColumnWidth {
+				atMinimum[i] = true
+				changed = true
+			}
+		}
+	}
+
+	// Rounding leaves a few cells over, which go to the leftmost columns
+	used := 0
+	for _, i := range visible {
+		if atMinimum[i] {
+			v.columnWidths[i] = MinFitColumnWidth
+		} else {
+			v.columnWidths[i] = v.contentWidths[i] * remaining / total
+		}
+		used += v.columnWidths[i]
+	}
+	for n := 0; used < area; n++ {
+		v.columnWidths[visible[n%len(visible)]]++
+		used++
+	}
+}
+
+// SetFitColumns turns fit mode, which sizes the columns to fill the
+// viewport width, on or off
+func (v *CSVViewer) SetFitColumns(fit bool) {
+	v.fitColumns = fit
+	v.updateColumnWidths()
+}
+
+// ToggleFitColumns switches fit mode on or off
+func (v *CSVViewer) ToggleFitColumns() {
+	v.SetFitColumns(!v.fitColumns)
+}
+
+// FitColumns reports whether the columns are sized to fill the viewport width
+func (v *CSVViewer) FitColumns() bool {
+	return v.fitColumns
+}
+
+// SetNumberFormat sets how the cells of numeric columns are written and
+// resizes the columns to match
+func (v *CSVViewer) SetNumberFormat(format model.NumberFormat) {
+	v.numberFormat = format
+	v.calculateColumnWidths()
+}
+
+// NumberFormat returns how the cells of numeric columns are written
+func (v *CSVViewer) NumberFormat() model.NumberFormat {
+	return v.numberFormat
+}
+
+// displayCell returns a cell as shown in the table, with numbers in
+// numeric columns written in the number format
+func (v *CSVViewer) displayCell(col int, cell string) string {
+	if v.numberFormat.IsZero() || !v.data.ColumnTypeOf(col).IsNumeric() {
+		return cell
+	}
+	return v.numberFormat.FormatString(cell)
+}
+
+// columnAreaWidth returns how many cells the columns may span: the viewport
+// less the table border and the row number gutter, or 0 if it is unlimited
+func (v *CSVViewer) columnAreaWidth() int {
+	if v.viewportWidth <= 0 {
+		return 0
+	}
+	width := v.viewportWidth - 2
+	if v.ShowRowNumbers {
+		width -= v.rowNumberWidth()
+	}
+	return max(width, 1)
+}
+
+// renderedWidth returns how wide column i is drawn, which is narrow when it is hidden
+func (v *CSVViewer) renderedWidth(i int) int {
+	if !v.data.ColumnVisibility[i] {
+		return collapsedColumnWidth
+	}
+	return v.columnWidths[i]
+}
+
+// frozenCount returns how many leading columns are frozen, at most all of them
+func (v *CSVViewer) frozenCount() int {
+	return max(min(v.FrozenColumns, len(v.data.Headers)), 0)
+}
+
+// columnWindow returns the scrolled columns that fit on screen beside the
+// frozen ones, from viewportX up to but not including end. The first of
+// them is always shown, however wide.
+func (v *CSVViewer) columnWindow() (start, end int) {
+	frozen := v.frozenCount()
+	start = max(min(v.viewportX, len(v.data.Headers)-1), frozen)
+	area := v.columnAreaWidth()
+
+	used := 0
+	for i := 0; i < frozen; i++ {
+		used += v.renderedWidth(i)
+	}
+	for end = start; end < len(v.data.Headers); end++ {
+		width := v.renderedWidth(end)
+		if area > 0 && end > start && used+width > area {
+			break
+		}
+		used += width
+	}
+	return start, end
+}
+
+// screenColumns returns the indices of the columns on screen, in the order
+// they are drawn: the frozen columns, then the scrolled window
+func (v *CSVViewer) screenColumns() []int {
+	start, end := v.columnWindow()
+	columns := make([]int, 0, v.frozenCount()+end-start)
+	for i := 0; i < v.frozenCount(); i++ {
+		columns = append(columns, i)
+	}
+	for i := start; i < end; i++ {
+		columns = append(columns, i)
+	}
+	return columns
+}
+
+// ToggleFreezeColumns freezes the columns up to and including the current
+// one, or unfreezes them all if any are frozen
+func (v *CSVViewer) ToggleFreezeColumns() {
+	if v.FrozenColumns > 0 {
+		v.FrozenColumns = 0
+	} else {
+		v.FrozenColumns = v.cursorCol + 1
+	}
+	v.ensureCursorVisible()
+}
+
+// SetColumnMaxWidth sets the width beyond which cells are truncated and
+// re-lays out the columns. Widths below MinColumnMaxWidth are raised to it.
+func (v *CSVViewer) SetColumnMaxWidth(width int) {
+	v.columnMaxWidth = max(width, MinColumnMaxWidth)
+	v.updateColumnWidths()
+}
+
+// ColumnMaxWidth returns the width beyond which cells are truncated
+func (v *CSVViewer) ColumnMaxWidth() int {
+	return v.columnMaxWidth
+}
+
+// WidenColumns raises the column width cap by one step
+func (v *CSVViewer) WidenColumns() {
+	v.SetColumnMaxWidth(v.columnMaxWidth + ColumnMaxWidthStep)
+}
+
+// NarrowColumns lowers the column width cap by one step
+func (v *CSVViewer) NarrowColumns() {
+	v.SetColumnMaxWidth(v.columnMaxWidth - ColumnMaxWidthStep)
+}
+
+// SetViewport sets the viewport dimensions. The height is the total number
+// of lines the rendered table may occupy, including its border and header.
 func (v *CSVViewer) SetViewport(width, height int) {
+	width, height = clampViewportWidth(width), clampViewportHeight(height)
+
+	// Re-applying the same size keeps a viewport scrolled away from the cursor
+	if width == v.viewportWidth && height == v.viewportHeight {
+		return
+	}
 	v.viewportWidth = width
 	v.viewportHeight = height
-	v.ensureCursorVisible()
+	v.updateColumnWidths()
+}
+
+// ScrollUp moves the viewport up n rows, or n columns while transposed,
+// leaving the cursor where it is
+func (v *CSVViewer) ScrollUp(n int) {
+	if v.transposed {
+		v.viewportX = max(v.viewportX-n, 0)
+		return
+	}
+	v.viewportY = max(v.viewportY-n, 0)
+}
+
+// ScrollDown moves the viewport down n rows, or n columns while transposed,
+// leaving the cursor where it is, and stops once the last line reaches the
+// bottom of the table
+func (v *CSVViewer) ScrollDown(n int) {
+	if v.transposed {
+		maxX := max(len(v.data.Headers)-v.visibleRowCount(), 0)
+		v.viewportX = max(min(v.viewportX+n, maxX), v.viewportX)
+		return
+	}
+	maxY := max(len(v.displayRows)-v.visibleRowCount(), 0)
+	v.viewportY = max(min(v.viewportY+n, maxY), v.viewportY)
 }
 
-// Move cursor methods
+// MoveUp moves the cursor to the previous row, or to the previous column
+// while transposed
 func (v *CSVViewer) MoveUp() {
+	if v.transposed {
+		v.prevColumn()
+	} else {
+		v.prevRow()
+	}
+}
+
+// MoveDown moves the cursor to the next row, or to the next column while transposed
+func (v *CSVViewer) MoveDown() {
+	if v.transposed {
+		v.nextColumn()
+	} else {
+		v.nextRow()
+	}
+}
+
+// MoveLeft moves the cursor to the previous column, or to the previous row
+// while transposed
+func (v *CSVViewer) MoveLeft() {
+	if v.transposed {
+		v.prevRow()
+	} else {
+		v.prevColumn()
+	}
+}
+
+// MoveRight moves the cursor to the next column, or to the next row while transposed
+func (v *CSVViewer) MoveRight() {
+	if v.transposed {
+		v.nextRow()
+	} else {
+		v.nextColumn()
+	}
+}
+
+// prevRow moves the cursor to the previous row, stopping on the first one
+func (v *CSVViewer) prevRow() {
+	if v.WrapNavigation && v.cursorRow == 0 && len(v.displayRows) > 0 {
+		v.cursorRow = len(v.displayRows) - 1
+		v.ensureCursorVisible()
+		return
+	}
 	if v.cursorRow > 0 {
 		v.cursorRow--
 		v.ensureCursorVisible()
 	}
 }
 
-func (v *CSVViewer) MoveDown() {
-	if v.cursorRow < len(v.data.Rows) {
+// nextRow moves the cursor to the next row, stopping on the last one
+func (v *CSVViewer) nextRow() {
+	if v.WrapNavigation && v.cursorRow >= len(v.displayRows)-1 {
+		v.cursorRow = 0
+		v.ensureCursorVisible()
+		return
+	}
+	if v.cursorRow < len(v.displayRows)-1 {
 		v.cursorRow++
 		v.ensureCursorVisible()
 	}
 }
 
-func (v *CSVViewer) MoveLeft() {
+// prevColumn moves the cursor to the previous column, stopping on the first one
+func (v *CSVViewer) prevColumn() {
+	if v.WrapNavigation && v.cursorCol == 0 && len(v.data.Headers) > 0 {
+		v.cursorCol = len(v.data.Headers) - 1
+		v.ensureCursorVisible()
+		return
+	}
 	if v.cursorCol > 0 {
 		v.cursorCol--
 		v.ensureCursorVisible()
 	}
 }
 
-func (v *CSVViewer) MoveRight() {
+// nextColumn moves the cursor to the next column, stopping on the last one
+func (v *CSVViewer) nextColumn() {
+	if v.WrapNavigation && v.cursorCol >= len(v.data.Headers)-1 {
+		v.cursorCol = 0
+		v.ensureCursorVisible()
+		return
+	}
 	if v.cursorCol < len(v.data.Headers)-1 {
 		v.cursorCol++
 		v.ensureCursorVisible()
 	}
 }
 
+// MoveToTop moves the cursor to the first row, or to the first column while
+// transposed
+func (v *CSVViewer) MoveToTop() {
+	if v.transposed {
+		v.cursorCol = 0
+	} else {
+		v.cursorRow = 0
+	}
+	v.ensureCursorVisible()
+}
+
+// MoveToBottom moves the cursor to the last row, or to the last column while
+// transposed
+func (v *CSVViewer) MoveToBottom() {
+	if v.transposed {
+		v.cursorCol = max(len(v.data.Headers)-1, 0)
+	} else {
+		v.cursorRow = max(len(v.displayRows)-1, 0)
+	}
+	v.ensureCursorVisible()
+}
+
+// Click moves the cursor to the cell at x, y, measured from the top-left
+// corner of the rendered table. Clicking the header selects the column
+// without changing the row; clicks on the border or past the last row or
+// column are ignored.
+func (v *CSVViewer) Click(x, y int) {
+	if v.transposed {
+		v.clickTransposed(x, y)
+		return
+	}
+
+	col := v.columnAt(x - 1) // The left border takes the first cell
+	if col < 0 {
+		return
+	}
+
+	// The top border and the header come before the rows
+	header := v.headerLines()
+	switch line := y - 1 - header; {
+	case y >= 1 && y <= header:
+		v.cursorCol = col
+	case line >= 0 && line < v.visibleRowCount() && v.viewportY+line < len(v.displayRows):
+		v.cursorCol = col
+		v.cursorRow = v.viewportY + line
+	}
+	v.ensureCursorVisible()
+}
+
+// columnAt returns the index of the column covering x, measured from the
+// start of a row, or -1 if x falls on the row number gutter or past the end
+func (v *CSVViewer) columnAt(x int) int {
+	if v.ShowRowNumbers {
+		x -= v.rowNumberWidth()
+	}
+	if x < 0 {
+		return -1
+	}
+
+	for _, i := range v.screenColumns() {
+		width := v.renderedWidth(i)
+		if x < width {
+			return i
+		}
+		x -= width
+	}
+	return -1
+}
+
+// InvertColumnVisibility flips the visibility of every column
+func (v *CSVViewer) InvertColumnVisibility() {
+	v.data.InvertColumnVisibility()
+	v.updateColumnWidths()
+	if v.filterQuery != "" {
+		v.applyFilter()
+	}
+}
+
+// ShowAllColumns makes every column visible again
+func (v *CSVViewer) ShowAllColumns() {
+	v.data.ShowAllColumns()
+	v.updateColumnWidths()
+	if v.filterQuery != "" {
+		v.applyFilter()
+	}
+}
+
+// JumpToColumn moves the cursor to the first visible column whose header
+// matches name, case-insensitively. Exact matches win over prefix matches,
+// which win over substring matches. It reports whether a column was found.
+func (v *CSVViewer) JumpToColumn(name string) bool {
+	query := strings.ToLower(strings.TrimSpace(name))
+	if query == "" {
+		return false
+	}
+
+	matchers := []func(header string) bool{
+		func(header string) bool { return header == query },
+		func(header string) bool { return strings.HasPrefix(header, query) },
+		func(header string) bool { return strings.Contains(header, query) },
+	}
+	for _, matches := range matchers {
+		for i, header := range v.data.Headers {
+			if v.data.IsColumnVisible(i) && matches(strings.ToLower(header)) {
+				v.cursorCol = i
+				v.ensureCursorVisible()
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // ToggleColumnVisibility toggles visibility of the current column
 func (v *CSVViewer) ToggleColumnVisibility() {
 	v.data.ToggleColumnVisibility(v.cursorCol)
+	v.updateColumnWidths()
+	// Filters only match visible cells, so re-evaluate them
+	if v.filterQuery != "" {
+		v.applyFilter()
+	}
+}
+
+// ToggleRowNumbers shows or hides the row number gutter
+func (v *CSVViewer) ToggleRowNumbers() {
+	v.ShowRowNumbers = !v.ShowRowNumbers
+	// The gutter takes room from the columns
+	v.updateColumnWidths()
+}
+
+// ToggleRuler shows or hides the column index line above the header
+func (v *CSVViewer) ToggleRuler() {
+	v.ShowRuler = !v.ShowRuler
+	v.ensureCursorVisible()
+}
+
+// headerLines returns how many lines the header takes: one, or two with the
+// ruler, which a transposed table leaves out
+func (v *CSVViewer) headerLines() int {
+	if v.ShowRuler && !v.transposed {
+		return 2
+	}
+	return 1
+}
+
+// rowNumberWidth returns the gutter width, sized to the largest row number
+func (v *CSVViewer) rowNumberWidth() int {
+	return len(strconv.Itoa(len(v.data.Rows))) + 2*defaultCellPadding
 }
 
-// SortByCurrentColumn sorts by the current column
+// IsolateColumn hides every column except the current one. Calling it again
+// restores the visibility the columns had before isolating.
+func (v *CSVViewer) IsolateColumn() {
+	if v.isolatedVisibility != nil {
+		copy(v.data.ColumnVisibility, v.isolatedVisibility)
+		v.isolatedVisibility = nil
+	} else {
+		v.isolatedVisibility = append([]bool(nil), v.data.ColumnVisibility...)
+		for i := range v.data.ColumnVisibility {
+			v.data.ColumnVisibility[i] = i == v.cursorCol
+		}
+	}
+	v.updateColumnWidths()
+
+	// Filters only match visible cells, so re-evaluate them
+	if v.filterQuery != "" {
+		v.applyFilter()
+	}
+}
+
+// SortByCurrentColumn sorts by the current column. A transposed table isn't
+// sorted, as its columns are the rows being ordered.
 func (v *CSVViewer) SortByCurrentColumn() {
+	if v.transposed {
+		return
+	}
 	ascending := true
 	if v.data.SortColumn == v.cursorCol {
 		// Toggle order if already sorting by this column
 		ascending = !v.data.SortAsc
 	}
 	v.data.SortByColumn(v.cursorCol, ascending)
+	// Re-apply the filter so the displayed subset follows the new order
+	v.applyFilter()
+}
+
+// FilterRows restricts the displayed rows to those where any visible cell
+// contains the query (case-insensitive). An empty query shows all rows.
+func (v *CSVViewer) FilterRows(query string) {
+	v.setFilter(query, false)
+	v.applyFilter()
+	v.cursorRow = 0
+	v.viewportY = 0
+}
+
+// FilterRowsRegexp shows only the rows with a visible cell matching the
+// regular expression pattern, e.g. ^active$ for cells holding exactly
+// "active". Unlike FilterRows it is case-sensitive unless the pattern starts
+// with (?i). An invalid pattern is returned as an error and leaves the
+// current filter in place.
+func (v *CSVViewer) FilterRowsRegexp(pattern string) error {
+	if err := v.setFilter(pattern, true); err != nil {
+		return err
+	}
+	v.applyFilter()
+	v.cursorRow = 0
+	v.viewportY = 0
+	return nil
+}
+
+// setFilter makes query the filter, scoped to the column it names, and
+// compiles the text after the column as a regular expression when asRegexp
+// is set. An invalid expression is returned as an error and leaves the
+// filter unchanged. The rows are filtered by the next applyFilter.
+func (v *CSVViewer) setFilter(query string, asRegexp bool) error {
+	column, text := v.scopeQuery(query)
+	var re *regexp.Regexp
+	if asRegexp && query != "" {
+		var err error
+		if re, err = regexp.Compile(text); err != nil {
+			return err
+		}
+	}
+	v.filterQuery, v.filterRegexp = query, re
+	v.filterColumn, v.filterText = column, text
+	return nil
+}
+
+// ClearFilter removes the active filter and restores all rows
+func (v *CSVViewer) ClearFilter() {
+	v.FilterRows("")
+}
+
+// SetDuplicateMode restricts the displayed rows to the duplicates or the
+// first occurrences of the cell at colIndex, or of whole rows when colIndex
+// is negative. DuplicatesOff shows every row again.
+func (v *CSVViewer) SetDuplicateMode(mode DuplicateMode, colIndex int) {
+	v.duplicateMode, v.duplicateColumn = mode, colIndex
+	v.applyFilter()
+	v.cursorRow = 0
+	v.viewportY = 0
+}
+
+// CycleDuplicateMode steps through mode on the current column, mode on
+// whole rows, and off
+func (v *CSVViewer) CycleDuplicateMode(mode DuplicateMode) {
+	switch {
+	case v.duplicateMode != mode:
+		v.SetDuplicateMode(mode, v.cursorCol)
+	case v.duplicateColumn >= 0:
+		v.SetDuplicateMode(mode, -1)
+	default:
+		v.SetDuplicateMode(DuplicatesOff, -1)
+	}
+}
+
+// DuplicateMode returns how the rows are restricted by repeats, and the
+// column keying them, which is negative for whole rows
+func (v *CSVViewer) DuplicateMode() (DuplicateMode, int) {
+	return v.duplicateMode, v.duplicateColumn
+}
+
+// FilterQuery returns the active filter query
+func (v *CSVViewer) FilterQuery() string {
+	return v.filterQuery
+}
+
+// scopeQuery splits a "column:value" filter query into the index of the
+// column whose header is the part before the first colon, compared
+// case-insensitively, and the value, ignoring spaces after the colon.
+// Queries without a colon, or whose prefix names no column, match the whole
+// query against every column.
+func (v *CSVViewer) scopeQuery(query string) (int, string) {
+	name, value, found := strings.Cut(query, ":")
+	if !found {
+		return -1, query
+	}
+	for i, header := range v.data.Headers {
+		if strings.EqualFold(strings.TrimSpace(header), strings.TrimSpace(name)) {
+			return i, strings.TrimLeft(value, " ")
+		}
+	}
+	return -1, query
+}
+
+// FilterIsRegexp reports whether the filter query is a regular expression
+func (v *CSVViewer) FilterIsRegexp() bool {
+	return v.filterRegexp != nil
+}
+
+// RowCount returns the number of rows currently displayed
+func (v *CSVViewer) RowCount() int {
+	return len(v.displayRows)
+}
+
+// CursorPosition returns the 1-based position of the cursor among the
+// displayed rows, or 0 if there are none
+func (v *CSVViewer) CursorPosition() int {
+	if len(v.displayRows) == 0 {
+		return 0
+	}
+	return v.cursorRow + 1
+}
+
+// CurrentCellValue returns the full value of the cell under the cursor, or
+// "" when no row is displayed
+func (v *CSVViewer) CurrentCellValue() string {
+	if v.cursorRow >= len(v.displayRows) || v.cursorCol >= len(v.data.Headers) {
+		return ""
+	}
+	if row := v.data.Rows[v.displayRows[v.cursorRow]]; v.cursorCol < len(row) {
+		return row[v.cursorCol]
+	}
+	return ""
+}
+
+// CurrentColumnName returns the header of the column under the cursor
+func (v *CSVViewer) CurrentColumnName() string {
+	if v.cursorCol >= len(v.data.Headers) {
+		return ""
+	}
+	return v.data.Headers[v.cursorCol]
+}
+
+// ScrollPercent returns how far the viewport has scrolled through the
+// displayed rows, from 0 to 100, and whether every row fits on screen
+func (v *CSVViewer) ScrollPercent() (int, bool) {
+	if v.transposed {
+		start, end := v.transposedRowWindow()
+		return scrollPercent(start, len(v.displayRows), end-start)
+	}
+	return scrollPercent(v.viewportY, len(v.displayRows), v.visibleRowCount())
+}
+
+// TotalRowCount returns the number of rows in the underlying data
+func (v *CSVViewer) TotalRowCount() int {
+	return len(v.data.Rows)
+}
+
+// ExportCSV writes the view as CSV: the rows currently displayed, in their
+// sorted order, with hidden columns left out
+func (v *CSVViewer) ExportCSV(w io.Writer) error {
+	columns := v.data.GetVisibleColumns()
+	if len(columns) == 0 {
+		return fmt.Errorf("no visible columns to export")
+	}
+
+	csvWriter := csv.NewWriter(w)
+	record := make([]string, len(columns))
+	writeRecord := func(cells []string) error {
+		for i, col := range columns {
+			record[i] = ""
+			if col < len(cells) {
+				record[i] = cells[col]
+			}
+		}
+		return csvWriter.Write(record)
+	}
+
+	if err := writeRecord(v.data.Headers); err != nil {
+		return fmt.Errorf("failed to write CSV headers: %w", err)
+	}
+	for _, rowIdx := range v.displayRows {
+		if err := writeRecord(v.data.Rows[rowIdx]); err != nil {
+			return fmt.Errorf("failed to write CSV rows: %w", err)
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// applyFilter rebuilds displayRows from the active filter query and duplicate mode
+func (v *CSVViewer) applyFilter() {
+	v.stats, v.distinct = nil, nil
+	v.displayRows = make([]int, 0, len(v.data.Rows))
+	query := strings.ToLower(v.filterText)
+
+	var keep map[int]bool
+	if v.duplicateMode != DuplicatesOff {
+		var rows []int
+		if v.duplicateMode == UniqueOnly {
+			rows = v.data.FirstOccurrences(v.duplicateColumn)
+		} else {
+			rows = v.data.FindDuplicates(v.duplicateColumn)
+		}
+		keep = make(map[int]bool, len(rows))
+		for _, rowIdx := range rows {
+			keep[rowIdx] = true
+		}
+	}
+
+	for rowIdx, row := range v.data.Rows {
+		if keep != nil && !keep[rowIdx] {
+			continue
+		}
+		if query == "" || v.rowMatches(row, query) {
+			v.displayRows = append(v.displayRows, rowIdx)
+		}
+	}
+	v.ensureCursorVisible()
+}
+
+// rowMatches reports whether any visible cell in row contains the lowercased
+// query, or matches the filter's regular expression when it has one. A
+// query scoped to a column only looks at that column, even if it is hidden.
+func (v *CSVViewer) rowMatches(row []string, query string) bool {
+	if v.filterColumn >= 0 {
+		return v.filterColumn < len(row) && v.cellMatches(row[v.filterColumn], query)
+	}
+	for i, cell := range row {
+		if v.data.IsColumnVisible(i) && v.cellMatches(cell, query) {
+			return true
+		}
+	}
+	return false
+}
+
+// cellMatches reports whether a cell satisfies the filter
+func (v *CSVViewer) cellMatches(cell, query string) bool {
+	if v.filterRegexp != nil {
+		return v.filterRegexp.MatchString(cell)
+	}
+	return strings.Contains(strings.ToLower(cell), query)
 }
 
 // ensureCursorVisible adjusts viewport to keep cursor in view
 func (v *CSVViewer) ensureCursorVisible() {
+	if v.transposed {
+		v.ensureTransposedCursorVisible()
+		return
+	}
+
 	// Adjust vertical viewport
+	visibleRows := v.visibleRowCount()
 	if v.cursorRow < v.viewportY {
 		v.viewportY = v.cursorRow
-	} else if v.cursorRow >= v.viewportY+v.viewportHeight-1 { // -1 for header
-		v.viewportY = v.cursorRow - v.viewportHeight + 2
+	} else if v.cursorRow >= v.viewportY+visibleRows {
+		v.viewportY = v.cursorRow - visibleRows + 1
+	}
+
+	// Don't leave blank space below the last row, e.g. after a resize
+	if maxY := max(len(v.displayRows)-visibleRows, 0); v.viewportY > maxY {
+		v.viewportY = maxY
+	}
+
+	// Scroll sideways until the cursor's column is on screen. Frozen
+	// columns always are, and the window starts after them.
+	v.viewportX = max(v.viewportX, v.frozenCount())
+	if v.cursorCol < v.frozenCount() {
+		return
+	}
+	if v.cursorCol < v.viewportX {
+		v.viewportX = v.cursorCol
+	}
+	for v.viewportX < v.cursorCol {
+		if _, end := v.columnWindow(); v.cursorCol < end {
+			break
+		}
+		v.viewportX++
+	}
+}
+
+// visibleRowCount returns how many data rows fit in the viewport. The
+// viewport height covers the whole table and the pane below it, so the
+// border, the pinned header line and the pane are subtracted.
+func (v *CSVViewer) visibleRowCount() int {
+	rows := v.viewportHeight - tableChromeLines - (v.headerLines() - 1)
+	if pane := v.renderPane(); pane != "" {
+		rows -= lipgloss.Height(pane)
+	}
+	return max(rows, 1)
+}
+
+// CurrentRowNode builds a JSON object of the row under the cursor, keyed by
+// header in column order, or returns nil when there is no row to show.
+// Cells of numeric columns become JSON numbers.
+func (v *CSVViewer) CurrentRowNode() *model.JSONNode {
+	if v.cursorRow >= len(v.displayRows) {
+		return nil
+	}
+
+	row := v.data.Rows[v.displayRows[v.cursorRow]]
+	object := make(model.OrderedObject, 0, len(v.data.Headers))
+	for i, header := range v.data.Headers {
+		var cell string
+		if i < len(row) {
+			cell = row[i]
+		}
+		object = append(object, model.ObjectEntry{Key: header, Value: v.cellJSONValue(i, cell)})
+	}
+	return model.NewJSONNode("root", object, nil)
+}
+
+// cellJSONValue returns a cell as a JSON number when it is in a numeric
+// column and reads back unchanged as one, so e.g. "007" stays a string
+func (v *CSVViewer) cellJSONValue(col int, cell string) interface{} {
+	if !v.data.ColumnTypeOf(col).IsNumeric() {
+		return cell
+	}
+	if f, err := strconv.ParseFloat(cell, 64); err == nil && model.FloatToString(f) == cell {
+		return f
+	}
+	return cell
+}
+
+// ToggleCellDetail shows or hides the detail pane with the selected cell's full value
+func (v *CSVViewer) ToggleCellDetail() {
+	v.togglePane(paneCellDetail)
+}
+
+// ToggleColumnStats shows or hides the pane with the current column's statistics
+func (v *CSVViewer) ToggleColumnStats() {
+	v.togglePane(paneColumnStats)
+}
+
+// ToggleDistinctValues shows or hides the pane charting how often each
+// value of the current column occurs
+func (v *CSVViewer) ToggleDistinctValues() {
+	v.togglePane(paneDistinctValues)
+}
+
+// togglePane shows pane below the table in place of any other, or hides it
+// if it is already shown
+func (v *CSVViewer) togglePane(pane paneKind) {
+	if v.pane == pane {
+		v.pane = paneNone
+	} else {
+		v.pane = pane
+	}
+	v.ensureCursorVisible()
+}
+
+// renderPane renders the pane shown below the table, or "" if there is none
+func (v *CSVViewer) renderPane() string {
+	switch v.pane {
+	case paneCellDetail:
+		return v.RenderCellDetail()
+	case paneColumnStats:
+		return v.RenderColumnStats()
+	case paneDistinctValues:
+		return v.RenderDistinctValues()
+	default:
+		return ""
+	}
+}
+
+// ColumnStats returns statistics for a column over the displayed rows, so
+// only the rows matching the filter count while one is active
+func (v *CSVViewer) ColumnStats(colIndex int) parser.ColumnStats {
+	if v.stats == nil || v.statsColumn != colIndex {
+		stats := v.data.ColumnStatsFor(colIndex, v.displayRows)
+		v.stats, v.statsColumn = &stats, colIndex
+	}
+	return *v.stats
+}
+
+// RenderColumnStats renders the statistics of the current column under its
+// name and type: the count of cells, nulls and distinct values, and for
+// numeric columns the min, max, sum, mean and median
+func (v *CSVViewer) RenderColumnStats() string {
+	if v.cursorCol >= len(v.data.Headers) {
+		return ""
+	}
+
+	stats := v.ColumnStats(v.cursorCol)
+	title := headerStyle.Render(fmt.Sprintf("%s (%s)", v.data.Headers[v.cursorCol], stats.Type))
+	if v.filterQuery != "" {
+		title += rowNumberStyle.Render("filtered rows")
+	}
+
+	lines := []string{fmt.Sprintf("Count: %d | Nulls: %d | Distinct: %d", stats.Count, stats.Nulls, stats.Distinct)}
+	if stats.Type.IsNumeric() && stats.Count > stats.Nulls {
+		lines = append(lines, fmt.Sprintf("Min: %s | Max: %s | Sum: %s | Mean: %s | Median: %s",
+			formatStat(stats.Min), formatStat(stats.Max), formatStat(stats.Sum),
+			formatStat(stats.Mean), formatStat(stats.Median)))
+	}
+
+	body := lipgloss.NewStyle().Padding(0, defaultCellPadding).Render(strings.Join(lines, "\n"))
+	return tableStyle.Render(title + "\n" + body)
+}
+
+// DistinctValues returns the values of a column over the displayed rows,
+// from the most to the least common
+func (v *CSVViewer) DistinctValues(colIndex int) []parser.ValueCount {
+	if v.distinct == nil || v.distinctColumn != colIndex {
+		counts := v.data.DistinctCountsFor(colIndex, v.displayRows)
+		v.distinct, v.distinctColumn = parser.SortByFrequency(counts), colIndex
+	}
+	return v.distinct
+}
+
+// RenderDistinctValues renders the most common values of the current column
+// as a bar chart of their counts, followed by how many values were left out
+func (v *CSVViewer) RenderDistinctValues() string {
+	if v.cursorCol >= len(v.data.Headers) {
+		return ""
+	}
+
+	values := v.DistinctValues(v.cursorCol)
+	title := headerStyle.Render(fmt.Sprintf("%s: %d distinct %s", v.data.Headers[v.cursorCol],
+		len(values), pluralize("value", len(values))))
+	if v.filterQuery != "" {
+		title += rowNumberStyle.Render("filtered rows")
+	}
+	if len(values) == 0 {
+		return tableStyle.Render(title)
+	}
+
+	shown := values[:min(len(values), distinctMaxValues)]
+	labelWidth, countWidth := 0, len(strconv.Itoa(shown[0].Count))
+	for _, value := range shown {
+		labelWidth = max(labelWidth, ansi.StringWidth(distinctLabel(value.Value)))
+	}
+	labelWidth = min(labelWidth, distinctLabelWidth)
+
+	// Bars are scaled to the most common value and fill what's left of the pane
+	barWidth := min(distinctBarWidth, max(v.viewportWidth-labelWidth-countWidth-8, 1))
+	bar := "█"
+	if activeTheme.ASCII {
+		bar = "#"
+	}
+
+	var lines []string
+	for _, value := range shown {
+		label := truncate(distinctLabel(value.Value), labelWidth)
+		length := max(value.Count*barWidth/shown[0].Count, 1)
+		padding := strings.Repeat(" ", labelWidth-ansi.StringWidth(label))
+		lines = append(lines, fmt.Sprintf("%s%s  %*d %s", label, padding, countWidth, value.Count, strings.Repeat(bar, length)))
+	}
+	if more := len(values) - len(shown); more > 0 {
+		lines = append(lines, fmt.Sprintf("... and %d more", more))
+	}
+
+	body := lipgloss.NewStyle().Padding(0, defaultCellPadding).Render(strings.Join(lines, "\n"))
+	return tableStyle.Render(title + "\n" + body)
+}
+
+// distinctLabel names a value in the distinct values pane, where an empty
+// cell would otherwise leave a blank
+func distinctLabel(value string) string {
+	if value == "" {
+		return "(empty)"
 	}
+	return value
+}
+
+// formatStat formats a statistic with at most four decimal places
+func formatStat(value float64) string {
+	return strconv.FormatFloat(math.Round(value*1e4)/1e4, 'f', -1, 64)
+}
+
+// RenderCellDetail renders the full, untruncated value of the selected cell
+// under its column name, wrapped to the viewport width
+func (v *CSVViewer) RenderCellDetail() string {
+	if v.cursorRow >= len(v.displayRows) || v.cursorCol >= len(v.data.Headers) {
+		return ""
+	}
+
+	// Leave room for the pane's border
+	wrapped := lipgloss.NewStyle().
+		Padding(0, defaultCellPadding).
+		Width(max(v.viewportWidth-2, MinColumnMaxWidth)).
+		Render(v.CurrentCellValue())
+	lines := strings.Split(wrapped, "\n")
+	if len(lines) > detailMaxLines {
+		lines = append(lines[:detailMaxLines-1], "...")
+	}
+
+	title := headerStyle.Render(v.data.Headers[v.cursorCol])
+	return tableStyle.Render(title + "\n" + strings.Join(lines, "\n"))
 }
 
 // Render renders the CSV viewer
 func (v *CSVViewer) Render() string {
+	if len(v.data.Headers) == 0 {
+		return "Empty CSV"
+	}
+
 	var table strings.Builder
+	if v.transposed {
+		v.writeTransposed(&table)
+	} else {
+		v.writeTable(&table)
+	}
 
-	// Create header
+	// Apply table border
+	result := tableStyle.Render(table.String())
+	if pane := v.renderPane(); pane != "" {
+		result += "\n" + pane
+	}
+	return result
+}
+
+// writeTable writes the header and the rows in the viewport, each row as a line
+func (v *CSVViewer) writeTable(table *strings.Builder) {
+	// The header is always written first so it stays pinned while the rows scroll
+	if v.ShowRuler {
+		table.WriteString(v.createRulerRow())
+		table.WriteString("\n")
+	}
 	headers := v.createHeaderRow()
 	table.WriteString(headers)
-	table.WriteString("\n")
 
 	// Calculate visible rows
-	startRow := v.viewportY
-	endRow := min(startRow+v.viewportHeight-2, len(v.data.Rows)) // -2 for header and spacing
+	startRow := min(max(v.viewportY, 0), len(v.displayRows))
+	endRow := min(startRow+v.visibleRowCount(), len(v.displayRows))
 
 	// Create data rows
 	for rowIdx := startRow; rowIdx < endRow; rowIdx++ {
 		dataRow := v.createDataRow(rowIdx)
+		table.WriteString("\n")
 		table.WriteString(dataRow)
+	}
+
+	// Say why the grid is empty rather than leaving just the header
+	if len(v.displayRows) == 0 {
 		table.WriteString("\n")
+		table.WriteString(v.emptyMessage())
 	}
+}
 
-	// Apply table border
-	result := tableStyle.Render(table.String())
-	return result
+// emptyMessage renders why no rows are displayed
+func (v *CSVViewer) emptyMessage() string {
+	message := "No rows"
+	if len(v.data.Rows) > 0 {
+		message = "No matching rows"
+	}
+	return typeAnnotationStyle.Render(" " + message)
+}
+
+// createRulerRow generates the line of 1-based column indices shown above the
+// header, aligned with the columns. Collapsed columns are left blank.
+func (v *CSVViewer) createRulerRow() string {
+	var cells []string
+	if v.ShowRowNumbers {
+		cells = append(cells, strings.Repeat(" ", v.rowNumberWidth()))
+	}
+
+	for _, i := range v.screenColumns() {
+		if !v.data.ColumnVisibility[i] {
+			cells = append(cells, strings.Repeat(" ", v.renderedWidth(i)))
+			continue
+		}
+
+		width := v.columnWidths[i]
+		style := rowNumberStyle.Copy().Width(width).AlignHorizontal(lipgloss.Left)
+		if v.data.ColumnTypeOf(i).IsNumeric() {
+			style = style.AlignHorizontal(lipgloss.Right)
+		}
+		cells = append(cells, style.Render(truncate(strconv.Itoa(i+1), width-2)))
+	}
+
+	return strings.Join(cells, "")
 }
 
 // createHeaderRow generates header row with consistent formatting
 func (v *CSVViewer) createHeaderRow() string {
 	var cells []string
 
-	// Create each header cell
-	for i, header := range v.data.Headers {
+	// The gutter sits outside the columns, so the column cursor never lands on it
+	if v.ShowRowNumbers {
+		cells = append(cells, rowNumberStyle.Copy().Width(v.rowNumberWidth()).Render("#"))
+	}
+
+	// Create a cell for each column on screen
+	for _, i := range v.screenColumns() {
+		header := v.data.Headers[i]
 		// Handle hidden columns
 		if !v.data.ColumnVisibility[i] {
 			// Create collapsed indicator
 			style := collapsedColHeaderStyle
 			if i == v.cursorCol {
-				style = style.Background(lipgloss.Color(HighlightColor))
+				style = style.Background(themeColor(activeTheme.Highlight))
 			}
 			cells = append(cells, style.Render(collapsedIndicator))
 			continue
@@ -228,15 +1386,16 @@ func (v *CSVViewer) createHeaderRow() string {
 			}
 		}
 
-		// Truncate if needed
-		if len(content) > width-2 {
-			content = content[:width-5] + "..."
-		}
+		// Truncate if needed, leaving room for the cell padding
+		content = truncate(content, width-2)
 
 		// Apply styling with fixed width
 		style := headerStyle.Copy().Width(width)
+		if v.data.ColumnTypeOf(i).IsNumeric() {
+			style = style.AlignHorizontal(lipgloss.Right)
+		}
 		if i == v.cursorCol {
-			style = style.Background(lipgloss.Color(HighlightColor))
+			style = style.Background(themeColor(activeTheme.Highlight))
 		}
 
 		// Render cell with exact width
@@ -246,23 +1405,35 @@ func (v *CSVViewer) createHeaderRow() string {
 	return strings.Join(cells, "")
 }
 
-// createDataRow generates a single data row with consistent formatting
+// createDataRow generates a single data row with consistent formatting.
+// rowIdx is a position in displayRows, not in the underlying data.
 func (v *CSVViewer) createDataRow(rowIdx int) string {
 	var cells []string
-	row := v.data.Rows[rowIdx]
+	dataIdx := v.displayRows[rowIdx]
+	row := v.data.Rows[dataIdx]
+	striped := activeTheme.Zebra && rowIdx%2 == 1
 
-	// Create each data cell
-	for i := range v.data.Headers {
+	if v.ShowRowNumbers {
+		number := strconv.Itoa(v.data.RowNumber(dataIdx))
+		style := rowNumberStyle.Copy().Width(v.rowNumberWidth())
+		if striped {
+			style = style.Background(themeColor(activeTheme.Stripe))
+		}
+		cells = append(cells, style.Render(number))
+	}
+
+	// Create a cell for each column on screen
+	for _, i := range v.screenColumns() {
 		// Handle hidden columns
 		if !v.data.ColumnVisibility[i] {
 			// Create collapsed indicator
 			style := collapsedColStyle
 			if i == v.cursorCol && rowIdx == v.cursorRow {
-				style = style.Background(lipgloss.Color(HighlightColor))
+				style = style.Background(themeColor(activeTheme.Highlight))
 			} else if i == v.cursorCol {
-				style = style.Background(lipgloss.Color(SecondaryColor))
+				style = style.Background(themeColor(activeTheme.Secondary))
 			} else if rowIdx == v.cursorRow {
-				style = style.Background(lipgloss.Color(BackgroundColor))
+				style = style.Background(themeColor(activeTheme.Background))
 			}
 			cells = append(cells, style.Render(collapsedIndicator))
 			continue
@@ -271,35 +1442,287 @@ func (v *CSVViewer) createDataRow(rowIdx int) string {
 		// Get cell content
 		var content string
 		if i < len(row) {
-			content = row[i]
+			content = v.displayCell(i, row[i])
 		}
 		width := v.columnWidths[i]
 
-		// Truncate if needed
-		if len(content) > width-2 {
-			content = content[:width-5] + "..."
-		}
+		// Truncate if needed, leaving room for the cell padding
+		content = truncate(content, width-2)
 
-		// Select styling based on cursor position
-		var style lipgloss.Style
+		// Stripe alternate rows, then let the cursor's highlight win
+		style := cellStyle
+		if striped {
+			style = stripedCellStyle
+		}
 		if rowIdx == v.cursorRow && i == v.cursorCol {
 			style = selectedCellStyle
 		} else if rowIdx == v.cursorRow {
 			style = selectedRowStyle
 		} else if i == v.cursorCol {
 			style = selectedColStyle
-		} else {
-			style = cellStyle
 		}
 
+		content = v.highlightFilter(content, i, style)
+
 		// Apply same width as headers for consistent alignment
 		style = style.Copy().Width(width)
+		if v.data.ColumnTypeOf(i).IsNumeric() {
+			style = style.AlignHorizontal(lipgloss.Right)
+		}
 		cells = append(cells, style.Render(content))
 	}
 
 	return strings.Join(cells, "")
 }
 
+// highlightFilter marks where the filter matched content, a cell of column
+// col drawn in style. The text around each match is styled by itself, as the
+// match's reset would end the cell's style.
+func (v *CSVViewer) highlightFilter(content string, col int, style lipgloss.Style) string {
+	// A filter scoped to a column only matched in that column
+	inScope := v.filterColumn < 0 || v.filterColumn == col
+	if inScope && v.filterRegexp != nil {
+		return highlightRegexp(content, v.filterRegexp, style.Copy().UnsetPadding())
+	} else if inScope && v.filterText != "" {
+		return highlightMatches(content, v.filterText, style.Copy().UnsetPadding())
+	}
+	return content
+}
+
+// ToggleTranspose switches between drawing the rows as lines and drawing
+// them as columns, each table column becoming a line headed by its name.
+// Only the drawing changes: the data, filter and sort order stay as they
+// are and the cursor stays on its cell, so switching back loses nothing.
+// Row numbers, the ruler, frozen and fitted columns only apply untransposed.
+func (v *CSVViewer) ToggleTranspose() {
+	v.transposed = !v.transposed
+	v.ensureCursorVisible()
+}
+
+// Transposed reports whether the table is drawn with its rows as columns
+func (v *CSVViewer) Transposed() bool {
+	return v.transposed
+}
+
+// transposedLabelWidth returns the width of the column of header names on
+// the left of a transposed table
+func (v *CSVViewer) transposedLabelWidth() int {
+	width := 0
+	for _, header := range v.data.Headers {
+		width = max(width, ansi.StringWidth(header)+4) // Padding and a sort indicator
+	}
+	return min(width, v.columnMaxWidth)
+}
+
+// transposedRowWidth returns how wide the displayed row at rowIdx is drawn
+// as a column of a transposed table: its widest cell, capped like a column
+func (v *CSVViewer) transposedRowWidth(rowIdx int) int {
+	dataIdx := v.displayRows[rowIdx]
+	width := len(strconv.Itoa(v.data.RowNumber(dataIdx))) + 2*defaultCellPadding
+	for i, cell := range v.data.Rows[dataIdx] {
+		if i < len(v.data.Headers) && v.data.IsColumnVisible(i) {
+			width = max(width, ansi.StringWidth(v.displayCell(i, cell))+2*defaultCellPadding)
+		}
+	}
+	return min(width, v.columnMaxWidth)
+}
+
+// transposedRowArea returns how many cells the rows of a transposed table
+// may span beside the header names, or 0 if it is unlimited
+func (v *CSVViewer) transposedRowArea() int {
+	if v.viewportWidth <= 0 {
+		return 0
+	}
+	return max(v.viewportWidth-2-v.transposedLabelWidth(), 1)
+}
+
+// transposedRowWindow returns the displayed rows that fit side by side in a
+// transposed table, from viewportY up to but not including end. The first
+// of them is always shown, however wide.
+func (v *CSVViewer) transposedRowWindow() (start, end int) {
+	start = min(max(v.viewportY, 0), len(v.displayRows))
+	area := v.transposedRowArea()
+
+	used := 0
+	for end = start; end < len(v.displayRows); end++ {
+		width := v.transposedRowWidth(end)
+		if area > 0 && end > start && used+width > area {
+			break
+		}
+		used += width
+	}
+	return start, end
+}
+
+// transposedColumnWindow returns the columns shown as lines of a transposed
+// table, from viewportX up to but not including end
+func (v *CSVViewer) transposedColumnWindow() (start, end int) {
+	start = min(max(v.viewportX, 0), len(v.data.Headers))
+	return start, min(start+v.visibleRowCount(), len(v.data.Headers))
+}
+
+// ensureTransposedCursorVisible scrolls a transposed table so the cursor's
+// column is among the lines and its row among the columns on screen
+func (v *CSVViewer) ensureTransposedCursorVisible() {
+	lines := v.visibleRowCount()
+	if v.cursorCol < v.viewportX {
+		v.viewportX = v.cursorCol
+	} else if v.cursorCol >= v.viewportX+lines {
+		v.viewportX = v.cursorCol - lines + 1
+	}
+	if maxX := max(len(v.data.Headers)-lines, 0); v.viewportX > maxX {
+		v.viewportX = maxX
+	}
+
+	row := min(v.cursorRow, len(v.displayRows)-1)
+	if row < v.viewportY {
+		v.viewportY = max(row, 0)
+		return
+	}
+	if _, end := v.transposedRowWindow(); row < end {
+		return
+	}
+
+	// Start the window as far left as still leaves the cursor's row on
+	// screen, measuring back from it rather than stepping forward from the
+	// old start one row at a time
+	area := v.transposedRowArea()
+	v.viewportY = row
+	for used := v.transposedRowWidth(row); v.viewportY > 0; v.viewportY-- {
+		width := v.transposedRowWidth(v.viewportY - 1)
+		if area > 0 && used+width > area {
+			break
+		}
+		used += width
+	}
+}
+
+// writeTransposed writes a transposed table: a header of row numbers, then
+// a line per column on screen with its name and its cell in each row
+func (v *CSVViewer) writeTransposed(table *strings.Builder) {
+	labelWidth := v.transposedLabelWidth()
+	rowStart, rowEnd := v.transposedRowWindow()
+
+	cells := []string{rowNumberStyle.Copy().Width(labelWidth).Render("#")}
+	for rowIdx := rowStart; rowIdx < rowEnd; rowIdx++ {
+		style := headerStyle.Copy().Width(v.transposedRowWidth(rowIdx))
+		if rowIdx == v.cursorRow {
+			style = style.Background(themeColor(activeTheme.Highlight))
+		}
+		cells = append(cells, style.Render(strconv.Itoa(v.data.RowNumber(v.displayRows[rowIdx]))))
+	}
+	table.WriteString(strings.Join(cells, ""))
+
+	if len(v.displayRows) == 0 {
+		table.WriteString("\n")
+		table.WriteString(v.emptyMessage())
+		return
+	}
+
+	colStart, colEnd := v.transposedColumnWindow()
+	for i := colStart; i < colEnd; i++ {
+		table.WriteString("\n")
+		table.WriteString(v.createTransposedLine(i, labelWidth, rowStart, rowEnd))
+	}
+}
+
+// createTransposedLine generates the line of a transposed table showing
+// column col: its name, then its cells in the displayed rows from rowStart
+// up to but not including rowEnd. A hidden column shows just its name.
+func (v *CSVViewer) createTransposedLine(col, labelWidth, rowStart, rowEnd int) string {
+	label := v.data.Headers[col]
+	if col == v.data.SortColumn {
+		if v.data.SortAsc {
+			label += sortAscIndicator
+		} else {
+			label += sortDescIndicator
+		}
+	}
+	labelStyle := headerStyle.Copy().Width(labelWidth)
+	if !v.data.ColumnVisibility[col] {
+		labelStyle = collapsedColHeaderStyle.Copy().Width(labelWidth)
+	}
+	if col == v.cursorCol {
+		labelStyle = labelStyle.Background(themeColor(activeTheme.Highlight))
+	}
+	cells := []string{labelStyle.Render(truncate(label, labelWidth-2))}
+	if !v.data.ColumnVisibility[col] {
+		return cells[0]
+	}
+
+	striped := activeTheme.Zebra && col%2 == 1
+	for rowIdx := rowStart; rowIdx < rowEnd; rowIdx++ {
+		row := v.data.Rows[v.displayRows[rowIdx]]
+		var content string
+		if col < len(row) {
+			content = v.displayCell(col, row[col])
+		}
+		width := v.transposedRowWidth(rowIdx)
+		content = truncate(content, width-2)
+
+		// The cursor's column runs along this line and its row down the screen
+		style := cellStyle
+		if striped {
+			style = stripedCellStyle
+		}
+		if rowIdx == v.cursorRow && col == v.cursorCol {
+			style = selectedCellStyle
+		} else if col == v.cursorCol {
+			style = selectedRowStyle
+		} else if rowIdx == v.cursorRow {
+			style = selectedColStyle
+		}
+		content = v.highlightFilter(content, col, style)
+
+		style = style.Copy().Width(width)
+		if v.data.ColumnTypeOf(col).IsNumeric() {
+			style = style.AlignHorizontal(lipgloss.Right)
+		}
+		cells = append(cells, style.Render(content))
+	}
+	return strings.Join(cells, "")
+}
+
+// clickTransposed moves the cursor to the cell of a transposed table at x,
+// y. Clicking the header of row numbers selects the row, and clicking a
+// column's name selects the column.
+func (v *CSVViewer) clickTransposed(x, y int) {
+	row := -1
+	if x -= 1 + v.transposedLabelWidth(); x >= 0 {
+		start, end := v.transposedRowWindow()
+		for rowIdx := start; rowIdx < end; rowIdx++ {
+			width := v.transposedRowWidth(rowIdx)
+			if x < width {
+				row = rowIdx
+				break
+			}
+			x -= width
+		}
+	}
+
+	colStart, colEnd := v.transposedColumnWindow()
+	switch line := y - 2; {
+	case y == 1 && row >= 0:
+		v.cursorRow = row
+	case line >= 0 && colStart+line < colEnd:
+		v.cursorCol = colStart + line
+		if row >= 0 {
+			v.cursorRow = row
+		}
+	}
+	v.ensureCursorVisible()
+}
+
+// truncate shortens s to fit in width terminal cells, ending it with "...".
+// It cuts on character boundaries by display width, so multi-byte and wide
+// characters are never split.
+func truncate(s string, width int) string {
+	if ansi.StringWidth(s) <= width {
+		return s
+	}
+	return ansi.Truncate(s, width, "...")
+}
+
 // min returns the minimum of two integers
 func min(a, b int) int {
 	if a < b {
@@ -308,6 +1731,32 @@ func min(a, b int) int {
 	return b
 }
 
+// scrollPercent returns the scroll position of a viewport starting at offset
+// in a list of total items, height of which fit on screen, as a percentage,
+// and whether the whole list fits
+func scrollPercent(offset, total, height int) (int, bool) {
+	scrollable := total - height
+	if scrollable <= 0 {
+		return 100, true
+	}
+	return max(0, min(offset*100/scrollable, 100)), false
+}
+
+// clampViewportHeight raises a viewport height to at least one line. Very
+// small terminals leave less room than the chrome around a viewer takes.
+func clampViewportHeight(height int) int {
+	return max(height, 1)
+}
+
+// clampViewportWidth raises a negative viewport width to one cell, keeping 0
+// for a viewport without a width limit
+func clampViewportWidth(width int) int {
+	if width < 0 {
+		return 1
+	}
+	return width
+}
+
 // GetColumnWidth returns the width of a specific column
 func (v *CSVViewer) GetColumnWidth(colIndex int) int {
 	if colIndex >= 0 && colIndex < len(v.columnWidths) {
@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"tablux/pkg/parser"
+)
+
+// newTestCSVViewer parses text as CSV and views it
+func newTestCSVViewer(t *testing.T, text string) *CSVViewer {
+	t.Helper()
+	data, err := parser.NewCSVParser().Parse([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewCSVViewer(data)
+	v.SetViewport(80, 20)
+	return v
+}
+
+func TestMoveDownStopsOnLastRow(t *testing.T) {
+	v := newTestCSVViewer(t, "name\nfirst\nsecond\nlast\n")
+	for range 10 {
+		v.MoveDown()
+	}
+	if got := v.CurrentCellValue(); got != "last" {
+		t.Fatalf("after moving down past the end the cell is %q, want %q", got, "last")
+	}
+}
+
+func TestCursorRowStaysInTable(t *testing.T) {
+	v := newTestCSVViewer(t, "id\n1\n2\n3\n")
+	v.MoveToBottom()
+	v.MoveDown()
+	v.MoveDown()
+	if last := len(v.data.Rows) - 1; v.cursorRow > last {
+		t.Fatalf("cursor is on row %d, past the last row %d", v.cursorRow, last)
+	}
+
+	// With no rows displayed the cursor stays on the first
+	v.FilterRows("nothing matches this")
+	v.MoveDown()
+	if v.cursorRow != 0 {
+		t.Fatalf("moving down with no rows put the cursor on row %d", v.cursorRow)
+	}
+}
+
+func TestRenderEmptyTable(t *testing.T) {
+	v := newTestCSVViewer(t, "id,name\n")
+	if out := ansi.Strip(v.Render()); !strings.Contains(out, "No rows") {
+		t.Errorf("a table of just headers renders without saying it has no rows:\n%s", out)
+	}
+
+	v = newTestCSVViewer(t, "id,name\n1,a\n")
+	v.FilterRows("zzz")
+	if out := ansi.Strip(v.Render()); !strings.Contains(out, "No matching rows") {
+		t.Errorf("a filter matching nothing renders without saying so:\n%s", out)
+	}
+}
+
+func TestCSVRenderTinyViewports(t *testing.T) {
+	for _, size := range [][2]int{{0, 0}, {1, 1}, {3, 2}, {-5, -5}, {80, -1}, {-1, 20}} {
+		v := newTestCSVViewer(t, "id,name,email\n1,a,a@example.com\n2,b,b@example.com\n")
+		v.SetViewport(size[0], size[1])
+		v.MoveDown()
+		v.MoveRight()
+		if out := v.Render(); out == "" {
+			t.Errorf("a %dx%d viewport renders nothing", size[0], size[1])
+		}
+		if v.visibleRowCount() < 1 {
+			t.Errorf("a %dx%d viewport shows %d rows, want at least 1", size[0], size[1], v.visibleRowCount())
+		}
+	}
+}
+
+func TestTruncateAccentedCell(t *testing.T) {
+	// The fixture's cells mix accented letters, which take one cell, with
+	// wide characters taking two, so fewer of those fit
+	fixture, err := os.ReadFile("../../test/accents.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := newTestCSVViewer(t, string(fixture))
+	for _, row := range v.Data().Rows {
+		cell := row[1]
+		for _, width := range []int{5, 9, 10, 17} {
+			got := truncate(cell, width)
+			if w := ansi.StringWidth(got); w > width {
+				t.Errorf("truncated to %d: %q is %d cells wide", width, got, w)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncated to %d: %q splits a character", width, got)
+			}
+			if got == cell {
+				continue
+			}
+			kept, ok := strings.CutSuffix(got, "...")
+			if !ok || !strings.HasPrefix(cell, kept) {
+				t.Errorf("truncated to %d: %q isn't the start of the cell followed by ...", width, got)
+			}
+		}
+	}
+}
+
+func TestRestoreStateKeepsTransposed(t *testing.T) {
+	const text = "id,name\n1,a\n2,b\n"
+	prev := newTestCSVViewer(t, text)
+	prev.ToggleTranspose()
+	prev.MoveRight()
+
+	v := newTestCSVViewer(t, text)
+	v.RestoreState(prev)
+	if !v.Transposed() {
+		t.Fatal("reloading dropped the transposed view")
+	}
+	if got := v.CursorPosition(); got != 2 {
+		t.Errorf("cursor is on row %d after reloading, want 2", got)
+	}
+}
+
+func TestRestoreStateRescopesRegexp(t *testing.T) {
+	prev := newTestCSVViewer(t, "status,name\nactive,bob\nidle,alice\n")
+	if err := prev.FilterRowsRegexp("status:^a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the status column the whole query is the expression, and no
+	// cell starts with "status:"
+	v := newTestCSVViewer(t, "state,name\nactive,bob\nidle,alice\n")
+	v.RestoreState(prev)
+	if !v.FilterIsRegexp() || v.FilterQuery() != "status:^a" {
+		t.Fatalf("reloading changed the filter to %q", v.FilterQuery())
+	}
+	if got := v.RowCount(); got != 0 {
+		t.Errorf("filter matches %d rows after the column was renamed, want 0", got)
+	}
+}
+
+func TestDuplicateModes(t *testing.T) {
+	v := newTestCSVViewer(t, "id,city\n1,Paris\n2,Rome\n3,Paris\n4,Oslo\n5,Rome\n")
+	ids := func() []string {
+		var got []string
+		for _, rowIdx := range v.displayRows {
+			got = append(got, v.data.Rows[rowIdx][0])
+		}
+		return got
+	}
+
+	for _, tc := range []struct {
+		mode DuplicateMode
+		want string
+	}{
+		{DuplicatesOnly, "1 2 3 5"},
+		{UniqueOnly, "1 2 4"},
+		{DuplicatesOff, "1 2 3 4 5"},
+	} {
+		v.SetDuplicateMode(tc.mode, 1)
+		if got := strings.Join(ids(), " "); got != tc.want {
+			t.Errorf("mode %d shows rows %s, want %s", tc.mode, got, tc.want)
+		}
+	}
+}
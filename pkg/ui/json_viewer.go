@@ -2,38 +2,128 @@ package ui
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"tablux/pkg/model"
+	"tablux/pkg/parser"
 )
 
 var (
-	// JSON node colors from theme
-	keyStyle           = KeyStyle
-	stringStyle        = StringStyle
-	numberStyle        = NumberStyle
-	boolStyle          = BoolStyle
-	nullStyle          = NullStyle
-	bracketStyle       = BracketStyle
-	selectedStyle      = SelectedNodeStyle
-	jsonSeparatorStyle = SeparatorStyle
-
-	// Tree symbols from theme
-	treeStyles = TreeSymbols
+	// JSON node styles, assigned from the theme by applyJSONStyles
+	keyStyle            lipgloss.Style
+	stringStyle         lipgloss.Style
+	numberStyle         lipgloss.Style
+	boolStyle           lipgloss.Style
+	nullStyle           lipgloss.Style
+	bracketStyle        lipgloss.Style
+	selectedStyle       lipgloss.Style
+	jsonSeparatorStyle  lipgloss.Style
+	searchMatchStyle    lipgloss.Style
+	indexStyle          lipgloss.Style
+	typeAnnotationStyle lipgloss.Style
+	numericStringStyle  lipgloss.Style
+	duplicateKeyStyle   lipgloss.Style
+	diffStyles          map[model.DiffKind]lipgloss.Style
+
+	// Strings that would read as a JSON number if they weren't quoted
+	numericStringPattern = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][+-]?\d+)?$`)
+
+	// Tree symbols, assigned from the theme by applyJSONStyles
+	treeStyles map[string]string
 
 	// Spacing between columns for readability
 	valuePadding = 2
+
+	// Markers starting each line of a diff, as in a unified diff
+	diffMarkers = map[model.DiffKind]string{
+		model.DiffSame:    "  ",
+		model.DiffAdded:   "+ ",
+		model.DiffRemoved: "- ",
+		model.DiffChanged: "~ ",
+	}
 )
 
+// diffMarkerWidth is the width of the marker column of a diff
+const diffMarkerWidth = 2
+
+// applyJSONStyles refreshes the JSON viewer styles from the theme
+func applyJSONStyles() {
+	keyStyle = KeyStyle
+	stringStyle = StringStyle
+	numberStyle = NumberStyle
+	boolStyle = BoolStyle
+	nullStyle = NullStyle
+	bracketStyle = BracketStyle
+	selectedStyle = SelectedNodeStyle
+	jsonSeparatorStyle = SeparatorStyle
+	searchMatchStyle = SearchMatchStyle
+	indexStyle = IndexStyle
+	typeAnnotationStyle = TypeAnnotationStyle
+	numericStringStyle = NumericStringStyle
+	duplicateKeyStyle = DuplicateKeyStyle
+	diffStyles = map[model.DiffKind]lipgloss.Style{
+		model.DiffAdded:   DiffAddedStyle,
+		model.DiffRemoved: DiffRemovedStyle,
+		model.DiffChanged: DiffChangedStyle,
+	}
+	treeStyles = TreeSymbols
+}
+
 // JSONViewer displays a JSON tree
 type JSONViewer struct {
 	root           *model.JSONNode
 	cursor         int
-	nodes          []*model.JSONNode // Flattened list for navigation
-	visibleNodes   []*model.JSONNode // Current visible nodes
+	visibleNodes   []*model.JSONNode // Flattened list of the nodes whose ancestors are all expanded
 	viewportY      int
 	viewportHeight int
+	viewportWidth  int // Lines are clipped to this many cells, or not at all when 0
+	xOffset        int // Cells scrolled off the left edge
 	maxKeyWidth    int // For alignment
+	searchQuery    string
+	searchMatches  []*model.JSONNode // Matching nodes in document order
+	searchIndex    int               // Position of the current match in searchMatches
+
+	// layout counts rebuilds of the node list, so what is derived from it
+	// can be kept until the tree is next expanded or collapsed
+	layout          int
+	pathLines       map[string]int // Line of each visible node by path, as of pathLinesLayout
+	pathLinesLayout int
+	matched         matchedLayouts // What MatchExpanded last matched
+
+	// ShowTypes appends each value's type, e.g. "string" or "number"
+	ShowTypes bool
+
+	// NumberFormat controls how numbers are written
+	NumberFormat model.NumberFormat
+
+	// Schema shows a tree built by model.InferSchema, whose string values
+	// are type names rather than data
+	Schema bool
+
+	// WrapNavigation makes moving past the last node continue from the first, and back
+	WrapNavigation bool
+	// Skipped holds why input records were left out of the tree, e.g.
+	// malformed lines parsed leniently
+	Skipped []error
+	// DuplicateKeys counts the keys the parser found repeated within an object
+	DuplicateKeys int
+
+	// Diff marks nodes by path as added, removed or changed, as one side of
+	// a model.TreeDiff. When set, lines start with a marker column and the
+	// keys of differing nodes are colored.
+	Diff map[string]model.DiffKind
+}
+
+// matchedLayouts records the leader and the layouts of both trees when
+// MatchExpanded last ran, so it can skip matching trees neither of which has
+// changed since
+type matchedLayouts struct {
+	leader       *JSONViewer
+	leaderLayout int
+	layout       int
 }
 
 // NewJSONViewer creates a new JSON viewer
@@ -47,82 +137,290 @@ func NewJSONViewer(root *model.JSONNode) *JSONViewer {
 	return viewer
 }
 
-// buildNodeList creates a flattened list of visible nodes
-func (v *JSONViewer) buildNodeList() {
-	v.nodes = make([]*model.JSONNode, 0)
-	v.visibleNodes = make([]*model.JSONNode, 0)
-	v.flattenNode(v.root, 0)
-	v.updateVisibleNodes()
+// Root returns the root node of the tree being displayed
+func (v *JSONViewer) Root() *model.JSONNode {
+	return v.root
 }
 
-// flattenNode adds a node and its visible children to the nodes list
-func (v *JSONViewer) flattenNode(node *model.JSONNode, depth int) {
-	v.nodes = append(v.nodes, node)
-
-	if !node.Expanded {
-		return
+// CurrentNode returns the node under the cursor, or nil if there is none
+func (v *JSONViewer) CurrentNode() *model.JSONNode {
+	if v.cursor >= 0 && v.cursor < len(v.visibleNodes) {
+		return v.visibleNodes[v.cursor]
 	}
+	return nil
+}
 
-	for _, child := range node.Children {
-		v.flattenNode(child, depth+1)
+// RestoreState moves the cursor to the node at the same path it was on in a
+// viewer of an earlier version of the same document, falling back to the
+// same line when that path no longer exists.
+func (v *JSONViewer) RestoreState(prev *JSONViewer) {
+	v.cursor = min(prev.cursor, max(len(v.visibleNodes)-1, 0))
+	if node := prev.CurrentNode(); node != nil {
+		for i, candidate := range v.visibleNodes {
+			if candidate.Path == node.Path {
+				v.cursor = i
+				break
+			}
+		}
 	}
+	v.viewportY = prev.viewportY
+	v.ensureCursorVisible()
 }
 
-// updateVisibleNodes updates the list of visible nodes
-func (v *JSONViewer) updateVisibleNodes() {
-	v.visibleNodes = make([]*model.JSONNode, 0)
+// Follow moves the cursor to the node at the path the leader's cursor is on,
+// or to its nearest ancestor this tree has, and scrolls so the cursor sits on
+// the same screen line as the leader's. Two panes showing versions of one
+// document stay in step this way.
+func (v *JSONViewer) Follow(leader *JSONViewer) {
+	// Every key press in a diff follows, so map paths to lines only when
+	// the node list changed rather than each time
+	if v.pathLines == nil || v.pathLinesLayout != v.layout {
+		v.pathLines = make(map[string]int, len(v.visibleNodes))
+		for i, node := range v.visibleNodes {
+			v.pathLines[node.Path] = i
+		}
+		v.pathLinesLayout = v.layout
+	}
+	for node := leader.CurrentNode(); node != nil; node = node.Parent {
+		if i, ok := v.pathLines[node.Path]; ok {
+			v.cursor = i
+			break
+		}
+	}
 
-	for _, node := range v.nodes {
-		// Check if node should be visible
-		parent := node.Parent
-		isVisible := true
+	maxY := max(len(v.visibleNodes)-v.viewportHeight, 0)
+	v.viewportY = max(min(v.cursor-(leader.cursor-leader.viewportY), maxY), 0)
+	v.xOffset = leader.xOffset
+}
 
-		for parent != nil {
-			if !parent.Expanded {
-				isVisible = false
-				break
-			}
-			parent = parent.Parent
+// MatchExpanded expands and collapses the nodes at the paths the leader has
+// built to match it, leaving nodes only this tree has as they are. Nothing
+// is walked when neither tree was expanded or collapsed since the last match.
+func (v *JSONViewer) MatchExpanded(leader *JSONViewer) {
+	last := matchedLayouts{leader: leader, leaderLayout: leader.layout, layout: v.layout}
+	if v.matched == last {
+		return
+	}
+
+	expanded := make(map[string]bool)
+	var collect func(node *model.JSONNode)
+	collect = func(node *model.JSONNode) {
+		expanded[node.Path] = node.Expanded
+		for _, child := range node.Children {
+			collect(child)
 		}
+	}
+	collect(leader.root)
 
-		if isVisible {
-			v.visibleNodes = append(v.visibleNodes, node)
+	changed := false
+	var apply func(node *model.JSONNode)
+	apply = func(node *model.JSONNode) {
+		if want, ok := expanded[node.Path]; ok && want != node.Expanded && node.HasChildren() {
+			node.Toggle()
+			changed = true
+		}
+		for _, child := range node.Children {
+			apply(child)
 		}
 	}
+	apply(v.root)
+
+	if changed {
+		v.rebuildNodeList()
+	}
+	v.matched = matchedLayouts{leader: leader, leaderLayout: leader.layout, layout: v.layout}
+}
+
+// ToggleTypes shows or hides the type annotations
+func (v *JSONViewer) ToggleTypes() {
+	v.ShowTypes = !v.ShowTypes
+}
+
+// buildNodeList creates a flattened list of visible nodes
+func (v *JSONViewer) buildNodeList() {
+	v.visibleNodes = v.flattenNode(v.root, make([]*model.JSONNode, 0))
 
 	// Make sure cursor is still valid
 	if v.cursor >= len(v.visibleNodes) && len(v.visibleNodes) > 0 {
 		v.cursor = len(v.visibleNodes) - 1
 	}
+	v.ensureCursorVisible()
+	v.layout++
+}
+
+// flattenNode appends a node and its visible descendants to nodes. Children
+// of collapsed nodes are skipped, so everything appended is visible.
+func (v *JSONViewer) flattenNode(node *model.JSONNode, nodes []*model.JSONNode) []*model.JSONNode {
+	nodes = append(nodes, node)
+
+	if !node.Expanded {
+		return nodes
+	}
+
+	for _, child := range node.Children {
+		nodes = v.flattenNode(child, nodes)
+	}
+	return nodes
 }
 
-// MoveUp moves the cursor up
+// refreshSubtree updates the lines below the node at index after it was
+// expanded or collapsed, replacing only its descendants rather than
+// flattening the whole tree again
+func (v *JSONViewer) refreshSubtree(index int) {
+	node := v.visibleNodes[index]
+	end := index + 1
+	for end < len(v.visibleNodes) && isDescendant(v.visibleNodes[end], node) {
+		end++
+	}
+
+	nodes := make([]*model.JSONNode, 0, len(v.visibleNodes))
+	nodes = append(nodes, v.visibleNodes[:index]...)
+	nodes = v.flattenNode(node, nodes)
+	v.visibleNodes = append(nodes, v.visibleNodes[end:]...)
+	v.layout++
+}
+
+// isDescendant reports whether node lies below ancestor in the tree
+func isDescendant(node, ancestor *model.JSONNode) bool {
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if parent == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// MoveUp moves the cursor up, wrapping to the last node if WrapNavigation is set
 func (v *JSONViewer) MoveUp() {
 	if v.cursor > 0 {
 		v.cursor--
+	} else if v.WrapNavigation {
+		v.cursor = max(len(v.visibleNodes)-1, 0)
 	}
 	v.ensureCursorVisible()
 }
 
-// MoveDown moves the cursor down
+// MoveDown moves the cursor down, wrapping to the first node if WrapNavigation is set
 func (v *JSONViewer) MoveDown() {
 	if v.cursor < len(v.visibleNodes)-1 {
 		v.cursor++
+	} else if v.WrapNavigation {
+		v.cursor = 0
 	}
 	v.ensureCursorVisible()
 }
 
+// MoveToTop moves the cursor to the first node
+func (v *JSONViewer) MoveToTop() {
+	v.cursor = 0
+	v.ensureCursorVisible()
+}
+
+// MoveToBottom moves the cursor to the last visible node
+func (v *JSONViewer) MoveToBottom() {
+	v.cursor = max(len(v.visibleNodes)-1, 0)
+	v.ensureCursorVisible()
+}
+
 // ToggleNode expands or collapses the current node
 func (v *JSONViewer) ToggleNode() {
 	if v.cursor < len(v.visibleNodes) {
 		node := v.visibleNodes[v.cursor]
 		if node.HasChildren() {
 			node.Toggle()
-			v.buildNodeList()
+			v.refreshSubtree(v.cursor)
+			v.ensureCursorVisible()
 		}
 	}
 }
 
+// rebuildNodeList rebuilds the node list after nodes were expanded or
+// collapsed, keeping the cursor on the node it was on. When a collapse hid
+// that node the cursor moves to the collapsed ancestor.
+func (v *JSONViewer) rebuildNodeList() {
+	current := v.CurrentNode()
+	v.buildNodeList()
+	v.anchorCursor(current)
+}
+
+// anchorCursor puts the cursor on node, or on its nearest visible ancestor
+func (v *JSONViewer) anchorCursor(node *model.JSONNode) {
+	for ; node != nil; node = node.Parent {
+		for i, candidate := range v.visibleNodes {
+			if candidate == node {
+				v.cursor = i
+				v.ensureCursorVisible()
+				return
+			}
+		}
+	}
+}
+
+// Click selects the node on line y of the rendered tree. A click on the
+// node's expand indicator, x cells from the left, also toggles the node.
+func (v *JSONViewer) Click(x, y int) {
+	if v.Diff != nil {
+		x -= diffMarkerWidth
+	}
+	x += v.xOffset
+	index := v.viewportY + y
+	if y < 0 || y >= v.viewportHeight || index >= len(v.visibleNodes) {
+		return
+	}
+	v.cursor = index
+
+	// The indicator ends the indentation
+	node := v.visibleNodes[index]
+	if node.HasChildren() {
+		indicator := treeStyles["expanded"]
+		if !node.Expanded {
+			indicator = treeStyles["collapsed"]
+		}
+		indicatorEnd := ansi.StringWidth(v.getIndentation(node))
+		if x >= indicatorEnd-ansi.StringWidth(indicator) && x < indicatorEnd {
+			v.ToggleNode()
+		}
+	}
+	v.ensureCursorVisible()
+}
+
+// ScrollUp moves the viewport up n lines, leaving the cursor where it is
+func (v *JSONViewer) ScrollUp(n int) {
+	v.viewportY = max(v.viewportY-n, 0)
+}
+
+// ScrollDown moves the viewport down n lines, leaving the cursor where it
+// is, and stops once the last node reaches the bottom of the viewport
+func (v *JSONViewer) ScrollDown(n int) {
+	maxY := max(len(v.visibleNodes)-v.viewportHeight, 0)
+	v.viewportY = max(min(v.viewportY+n, maxY), v.viewportY)
+}
+
+// ScrollLeft moves the view n cells to the left
+func (v *JSONViewer) ScrollLeft(n int) {
+	v.xOffset = max(v.xOffset-n, 0)
+}
+
+// ScrollRight moves the view n cells to the right, and stops once the end
+// of the widest line on screen is in view
+func (v *JSONViewer) ScrollRight(n int) {
+	if v.viewportWidth <= 0 {
+		return
+	}
+	width := v.viewportWidth
+	if v.Diff != nil {
+		width -= diffMarkerWidth
+	}
+
+	widest := 0
+	endIdx := min(v.viewportY+v.viewportHeight, len(v.visibleNodes))
+	for _, node := range v.visibleNodes[v.viewportY:endIdx] {
+		widest = max(widest, ansi.StringWidth(v.getIndentation(node)+v.formatNode(node)))
+	}
+	maxOffset := max(widest-width, 0)
+	v.xOffset = max(min(v.xOffset+n, maxOffset), min(v.xOffset, maxOffset))
+}
+
 // ensureCursorVisible adjusts viewport to keep cursor in view
 func (v *JSONViewer) ensureCursorVisible() {
 	if v.cursor < v.viewportY {
@@ -132,34 +430,274 @@ func (v *JSONViewer) ensureCursorVisible() {
 	}
 }
 
-// CollapseAll collapses all nodes in the tree
+// CollapseAll collapses all nodes in the tree. The cursor moves to the
+// nearest ancestor of its node left visible, which is the root.
 func (v *JSONViewer) CollapseAll() {
+	current := v.CurrentNode()
 	v.toggleAllNodes(v.root, false)
 	v.buildNodeList()
+	v.anchorCursor(current)
+	v.centerCursor()
 }
 
-// ExpandAll expands all nodes in the tree
+// ExpandAll expands all nodes in the tree, keeping the cursor on its node
+// and the viewport centered on it, as the lines around it change completely
 func (v *JSONViewer) ExpandAll() {
+	current := v.CurrentNode()
 	v.toggleAllNodes(v.root, true)
 	v.buildNodeList()
+	v.anchorCursor(current)
+	v.centerCursor()
+}
+
+// centerCursor scrolls the cursor's line to the middle of the viewport, as
+// far as the ends of the tree allow
+func (v *JSONViewer) centerCursor() {
+	maxY := max(len(v.visibleNodes)-v.viewportHeight, 0)
+	v.viewportY = max(min(v.cursor-v.viewportHeight/2, maxY), 0)
+}
+
+// ExpandNode expands node by one level, leaving its children collapsed, or
+// when recursive is set expands it and everything below it. The cursor
+// stays on the node it was on.
+func (v *JSONViewer) ExpandNode(node *model.JSONNode, recursive bool) {
+	if node == nil || !node.HasChildren() {
+		return
+	}
+	if recursive {
+		v.toggleAllNodes(node, true)
+	} else {
+		node.Expanded = true
+		node.LoadChildren()
+		for _, child := range node.Children {
+			if child.HasChildren() {
+				child.Expanded = false
+			}
+		}
+	}
+	v.rebuildNodeList()
 }
 
 // toggleAllNodes sets the expanded state for the given node and all its children
 func (v *JSONViewer) toggleAllNodes(node *model.JSONNode, expanded bool) {
 	if node.HasChildren() {
 		node.Expanded = expanded
+		if expanded {
+			node.LoadChildren()
+		}
 		for _, child := range node.Children {
 			v.toggleAllNodes(child, expanded)
 		}
 	}
 }
 
-// SetViewportHeight sets the height of the viewport
+// Search finds all nodes whose key or string value contains the query
+// (case-insensitive) and moves the cursor to the first match.
+// Matches inside collapsed nodes are included; their ancestors are expanded on demand.
+func (v *JSONViewer) Search(query string) {
+	v.searchQuery = query
+	v.searchMatches = nil
+	v.searchIndex = -1
+
+	if query == "" {
+		return
+	}
+
+	v.collectMatches(v.root, strings.ToLower(query))
+	v.NextMatch()
+}
+
+// ClearSearch removes the active search and its highlighting
+func (v *JSONViewer) ClearSearch() {
+	v.Search("")
+}
+
+// NextMatch moves the cursor to the next search match, wrapping at the end
+func (v *JSONViewer) NextMatch() {
+	if len(v.searchMatches) == 0 {
+		return
+	}
+	v.searchIndex = (v.searchIndex + 1) % len(v.searchMatches)
+	v.revealNode(v.searchMatches[v.searchIndex])
+}
+
+// PrevMatch moves the cursor to the previous search match, wrapping at the start
+func (v *JSONViewer) PrevMatch() {
+	if len(v.searchMatches) == 0 {
+		return
+	}
+	v.searchIndex = (v.searchIndex - 1 + len(v.searchMatches)) % len(v.searchMatches)
+	v.revealNode(v.searchMatches[v.searchIndex])
+}
+
+// FindByPath moves the cursor to the node at path, e.g. .users[2].name as
+// copied from the tree, expanding its ancestors as needed, and returns the
+// node's index in the node list. When no node is there the error says
+// where the path left the tree, e.g. at a missing key or index.
+func (v *JSONViewer) FindByPath(path string) (int, error) {
+	node, err := parser.FindPath(v.root, path)
+	if err != nil {
+		return 0, err
+	}
+	v.revealNode(node)
+	return v.cursor, nil
+}
+
+// CursorPosition returns the 1-based position of the cursor among the
+// visible nodes, or 0 if there are none
+func (v *JSONViewer) CursorPosition() int {
+	if len(v.visibleNodes) == 0 {
+		return 0
+	}
+	return v.cursor + 1
+}
+
+// VisibleNodeCount returns the number of nodes shown with the current expansion
+func (v *JSONViewer) VisibleNodeCount() int {
+	return len(v.visibleNodes)
+}
+
+// ScrollPercent returns how far the viewport has scrolled through the
+// visible nodes, from 0 to 100, and whether every node fits on screen
+func (v *JSONViewer) ScrollPercent() (int, bool) {
+	return scrollPercent(v.viewportY, len(v.visibleNodes), v.viewportHeight)
+}
+
+// SearchQuery returns the active search query
+func (v *JSONViewer) SearchQuery() string {
+	return v.searchQuery
+}
+
+// MatchCount returns the number of nodes matching the active search
+func (v *JSONViewer) MatchCount() int {
+	return len(v.searchMatches)
+}
+
+// CurrentMatch returns the 1-based position of the current match, or 0 if none
+func (v *JSONViewer) CurrentMatch() int {
+	return v.searchIndex + 1
+}
+
+// collectMatches walks the whole tree and records nodes matching the lowercased query
+func (v *JSONViewer) collectMatches(node *model.JSONNode, query string) {
+	if v.nodeMatches(node, query) {
+		v.searchMatches = append(v.searchMatches, node)
+	}
+	// Lazy subtrees have to be built to be searched
+	node.LoadChildren()
+	for _, child := range node.Children {
+		v.collectMatches(child, query)
+	}
+}
+
+// nodeMatches reports whether a node's key or string value contains the query
+func (v *JSONViewer) nodeMatches(node *model.JSONNode, query string) bool {
+	// The root key is never displayed, so don't match on it
+	if node.Parent != nil && strings.Contains(strings.ToLower(node.Key), query) {
+		return true
+	}
+	if node.Type == model.NodeString {
+		if s, ok := node.Value.(string); ok && strings.Contains(strings.ToLower(s), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// revealNode expands the node's ancestors and moves the cursor onto it
+func (v *JSONViewer) revealNode(node *model.JSONNode) {
+	changed := false
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		if !parent.Expanded {
+			parent.Expanded = true
+			changed = true
+		}
+	}
+	if changed {
+		v.buildNodeList()
+	}
+
+	for i, visible := range v.visibleNodes {
+		if visible == node {
+			v.cursor = i
+			break
+		}
+	}
+	v.ensureCursorVisible()
+}
+
+// highlightMatches renders text with style and wraps every case-insensitive
+// occurrence of query in the search match style
+func highlightMatches(text, query string, style lipgloss.Style) string {
+	lowerText := strings.ToLower(text)
+	lowerQuery := strings.ToLower(query)
+
+	// Lowercasing can change byte lengths for some runes; skip highlighting then
+	if query == "" || len(lowerText) != len(text) || len(lowerQuery) != len(query) {
+		return style.Render(text)
+	}
+
+	var sb strings.Builder
+	for {
+		idx := strings.Index(lowerText, lowerQuery)
+		if idx < 0 {
+			if text != "" {
+				sb.WriteString(style.Render(text))
+			}
+			break
+		}
+
+		if idx > 0 {
+			sb.WriteString(style.Render(text[:idx]))
+		}
+		end := idx + len(lowerQuery)
+		sb.WriteString(searchMatchStyle.Render(text[idx:end]))
+
+		text = text[end:]
+		lowerText = lowerText[end:]
+	}
+
+	return sb.String()
+}
+
+// highlightRegexp renders text with style, marking each non-empty match of
+// re with the search match style
+func highlightRegexp(text string, re *regexp.Regexp, style lipgloss.Style) string {
+	var sb strings.Builder
+	start := 0
+	for _, match := range re.FindAllStringIndex(text, -1) {
+		if match[0] == match[1] {
+			continue
+		}
+		if match[0] > start {
+			sb.WriteString(style.Render(text[start:match[0]]))
+		}
+		sb.WriteString(searchMatchStyle.Render(text[match[0]:match[1]]))
+		start = match[1]
+	}
+	if start < len(text) {
+		sb.WriteString(style.Render(text[start:]))
+	}
+	return sb.String()
+}
+
+// SetViewportHeight sets the height of the viewport, of at least one line
 func (v *JSONViewer) SetViewportHeight(height int) {
+	height = clampViewportHeight(height)
+	// Re-applying the same height keeps a viewport scrolled away from the cursor
+	if height == v.viewportHeight {
+		return
+	}
 	v.viewportHeight = height
 	v.ensureCursorVisible()
 }
 
+// SetViewportWidth sets how many cells wide rendered lines may be. Longer
+// lines are cut off with "...", and 0 leaves them whole.
+func (v *JSONViewer) SetViewportWidth(width int) {
+	v.viewportWidth = clampViewportWidth(width)
+}
+
 // RenderWithClosingBrackets renders the JSON with all closing brackets for static display
 func (v *JSONViewer) RenderWithClosingBrackets() string {
 	if len(v.visibleNodes) == 0 {
@@ -226,13 +764,14 @@ func (v *JSONViewer) Render() string {
 	var sb strings.Builder
 
 	// Calculate visible range
-	endIdx := v.viewportY + v.viewportHeight
+	startIdx := min(max(v.viewportY, 0), len(v.visibleNodes))
+	endIdx := startIdx + v.viewportHeight
 	if endIdx > len(v.visibleNodes) {
 		endIdx = len(v.visibleNodes)
 	}
 
 	// Render visible nodes
-	for i := v.viewportY; i < endIdx; i++ {
+	for i := startIdx; i < endIdx; i++ {
 		node := v.visibleNodes[i]
 		line := v.renderNode(node, i == v.cursor)
 		sb.WriteString(line)
@@ -247,46 +786,62 @@ func (v *JSONViewer) renderNode(node *model.JSONNode, selected bool) string {
 	indent := v.getIndentation(node)
 	nodeText := v.formatNode(node)
 
-	line := indent + nodeText
+	line := v.clipLine(indent + nodeText)
+	if v.Diff != nil {
+		// The marker stays put while the tree scrolls sideways
+		kind := v.Diff[node.Path]
+		line = diffStyles[kind].Render(diffMarkers[kind]) + line
+	}
 	if selected {
 		return selectedStyle.Render(line)
 	}
 	return line
 }
 
-// getIndentation returns the tree indentation for a node
+// clipLine fits a rendered line in the viewport width, starting from the
+// horizontal scroll offset
+func (v *JSONViewer) clipLine(line string) string {
+	if v.viewportWidth <= 0 {
+		return line
+	}
+	if v.xOffset > 0 {
+		line = ansi.TruncateLeft(line, v.xOffset, "")
+	}
+	width := v.viewportWidth
+	if v.Diff != nil {
+		width = max(width-diffMarkerWidth, 1)
+	}
+	return truncate(line, width)
+}
+
+// getIndentation returns the tree indentation for a node. Each ancestor
+// below the root contributes a column that continues with a pipe while that
+// ancestor has siblings still to come, and the node itself is joined to its
+// parent with a tee, or a last-child connector when it ends the list.
 func (v *JSONViewer) getIndentation(node *model.JSONNode) string {
 	var result strings.Builder
 
-	// Calculate ancestry for tree drawing
+	// Collect the ancestors below the root, outermost first
 	var ancestry []*model.JSONNode
-	current := node
-	for current.Parent != nil {
-		ancestry = append([]*model.JSONNode{current.Parent}, ancestry...)
-		current = current.Parent
-	}
-
-	// Draw tree branches
-	for i := 1; i < len(ancestry); i++ {
-		parent := ancestry[i]
-		isLast := false
-
-		if i == len(ancestry)-1 {
-			// Check if this is the last child of its parent
-			children := parent.Children
-			for j, child := range children {
-				if child == node && j == len(children)-1 {
-					isLast = true
-				}
-			}
-		}
+	for current := node.Parent; current != nil && current.Parent != nil; current = current.Parent {
+		ancestry = append([]*model.JSONNode{current}, ancestry...)
+	}
 
-		if isLast {
+	// Draw the columns of the ancestors, then the node's own connector
+	for _, ancestor := range ancestry {
+		if isLastChild(ancestor) {
 			result.WriteString(treeStyles["empty"])
 		} else {
 			result.WriteString(treeStyles["pipe"])
 		}
 	}
+	if node.Parent != nil {
+		if isLastChild(node) {
+			result.WriteString(treeStyles["last"])
+		} else {
+			result.WriteString(treeStyles["tee"])
+		}
+	}
 
 	// Add expand/collapse symbol if needed
 	if node.HasChildren() {
@@ -302,49 +857,97 @@ func (v *JSONViewer) getIndentation(node *model.JSONNode) string {
 	return result.String()
 }
 
+// isLastChild reports whether node is the final child of its parent
+func isLastChild(node *model.JSONNode) bool {
+	siblings := node.Parent.Children
+	return len(siblings) > 0 && siblings[len(siblings)-1] == node
+}
+
 // formatNode formats a node for display
 func (v *JSONViewer) formatNode(node *model.JSONNode) string {
-	key := node.Key
-	if key != "" && key != "root" {
-		key = fmt.Sprintf("\"%s\"", key)
-	} else if key == "root" {
-		key = ""
+	key := ""
+	var keyFormatted string
+	switch {
+	case node.Parent == nil:
+		// The root has no displayed key
+	case node.Parent.Type == model.NodeArray:
+		// Array elements are labelled by their position
+		key = fmt.Sprintf("[%d]", node.Index())
+		style := indexStyle
+		if kind := v.Diff[node.Path]; kind != model.DiffSame {
+			style = diffStyles[kind]
+		}
+		keyFormatted = style.Render(key)
+	default:
+		key = fmt.Sprintf("\"%s\"", node.Key)
+		style := keyStyle
+		if node.DuplicateKey {
+			style = duplicateKeyStyle
+		}
+		if kind := v.Diff[node.Path]; kind != model.DiffSame {
+			style = diffStyles[kind]
+		}
+		keyFormatted = highlightMatches(key, v.searchQuery, style)
 	}
 
-	keyFormatted := keyStyle.Render(key)
-
 	// Add colon and padding for better readability
 	separator := ""
 	if key != "" {
 		separator = jsonSeparatorStyle.Render(": " + strings.Repeat(" ", valuePadding))
 	}
 
+	var value string
 	switch node.Type {
 	case model.NodeObject:
 		if node.Expanded {
 			return keyFormatted + separator + bracketStyle.Render("{")
-		} else {
-			childCount := len(node.Children)
-			return keyFormatted + separator + bracketStyle.Render(fmt.Sprintf("{ %d %s }", childCount, pluralize("item", childCount)))
 		}
+		childCount := node.ChildCount()
+		value = bracketStyle.Render(fmt.Sprintf("{ %d %s }", childCount, pluralize("item", childCount)))
 	case model.NodeArray:
 		if node.Expanded {
 			return keyFormatted + separator + bracketStyle.Render("[")
-		} else {
-			childCount := len(node.Children)
-			return keyFormatted + separator + bracketStyle.Render(fmt.Sprintf("[ %d %s ]", childCount, pluralize("item", childCount)))
 		}
+		childCount := node.ChildCount()
+		value = bracketStyle.Render(fmt.Sprintf("[ %d %s ]", childCount, pluralize("item", childCount)))
 	case model.NodeString:
-		return keyFormatted + separator + stringStyle.Render(fmt.Sprintf("\"%s\"", node.Value.(string)))
+		str := node.Value.(string)
+		if v.Schema {
+			value = highlightMatches(str, v.searchQuery, typeAnnotationStyle)
+			break
+		}
+		value = highlightMatches(fmt.Sprintf("\"%s\"", str), v.searchQuery, stringStyle)
+		// Flag numbers stored as strings, a common schema mistake
+		if numericStringPattern.MatchString(str) {
+			value += numericStringStyle.Render(activeTheme.NumericStringMark)
+		}
 	case model.NodeNumber:
-		return keyFormatted + separator + numberStyle.Render(model.String(node.Value))
+		value = numberStyle.Render(v.NumberFormat.Format(node.Value))
 	case model.NodeBoolean:
-		return keyFormatted + separator + boolStyle.Render(model.String(node.Value))
+		word := model.String(node.Value)
+		if activeTheme.ValueSymbols {
+			word = activeTheme.FalseSymbol
+			if node.Value == true {
+				word = activeTheme.TrueSymbol
+			}
+		}
+		value = boolStyle.Render(word)
 	case model.NodeNull:
-		return keyFormatted + separator + nullStyle.Render("null")
+		word := "null"
+		if activeTheme.ValueSymbols {
+			word = activeTheme.NullSymbol
+		}
+		value = nullStyle.Render(word)
 	default:
-		return keyFormatted + separator + model.String(node.Value)
+		value = model.String(node.Value)
 	}
+
+	// Optional dimmed type label after the value
+	if v.ShowTypes {
+		value += typeAnnotationStyle.Render(" " + node.TypeString())
+	}
+
+	return keyFormatted + separator + value
 }
 
 // pluralize returns singular or plural word form
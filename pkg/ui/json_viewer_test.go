@@ -0,0 +1,193 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"tablux/pkg/model"
+)
+
+// benchmarkElements is how many objects the generated document's array
+// holds. Each is nine nodes with its fields, so the tree has about 50k.
+const benchmarkElements = 50000 / 9
+
+// generatedDocument builds an expanded tree of an array of n objects, each
+// with a nested object to toggle
+func generatedDocument(n int) *model.JSONNode {
+	elements := make([]interface{}, n)
+	for i := range elements {
+		elements[i] = map[string]interface{}{
+			"id":     float64(i),
+			"name":   fmt.Sprintf("user %d", i),
+			"active": i%2 == 0,
+			"tags":   []interface{}{"a", "b"},
+			"address": map[string]interface{}{
+				"city": "Springfield",
+			},
+		}
+	}
+	return model.NewJSONNode("root", elements, nil)
+}
+
+// assertFlattened fails unless the viewer's node list is the one a full
+// rebuild would produce
+func assertFlattened(t *testing.T, v *JSONViewer) {
+	t.Helper()
+	got := v.visibleNodes
+	v.buildNodeList()
+	want := v.visibleNodes
+	if len(got) != len(want) {
+		t.Fatalf("got %d visible nodes, a rebuild has %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("line %d is %s, a rebuild has %s", i, got[i].DisplayPath(), want[i].DisplayPath())
+		}
+	}
+}
+
+func TestToggleNodeMatchesRebuild(t *testing.T) {
+	v := NewJSONViewer(generatedDocument(50))
+
+	// Collapse and expand nodes at the start, middle and end of the list,
+	// nested ones among them, checking the list after each
+	for _, path := range []string{".[0]", ".[25].address", ".[25]", ".[49].tags", ".[49]", ".[25]", ".[25].address", ""} {
+		line := -1
+		for i, node := range v.visibleNodes {
+			if node.Path == path {
+				line = i
+				break
+			}
+		}
+		if line < 0 {
+			t.Fatalf("no visible node at %q", path)
+		}
+		v.cursor = line
+		node := v.CurrentNode()
+
+		v.ToggleNode()
+		if v.CurrentNode() != node {
+			t.Fatalf("toggling %q moved the cursor to %s", path, v.CurrentNode().DisplayPath())
+		}
+		assertFlattened(t, v)
+	}
+}
+
+// assertRenderFits fails unless rendered has at most height lines, each at
+// most width cells wide, with width 0 meaning any width
+func assertRenderFits(t *testing.T, rendered string, width, height int) {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(rendered, "\n"), "\n")
+	if len(lines) > height {
+		t.Errorf("rendered %d lines into a viewport of %d", len(lines), height)
+	}
+	for _, line := range lines {
+		if w := ansi.StringWidth(line); width > 0 && w > width {
+			t.Errorf("line %q is %d cells wide, more than %d", ansi.Strip(line), w, width)
+		}
+	}
+}
+
+func TestJSONRenderTinyViewports(t *testing.T) {
+	// Sizes below one are raised to one, except a width of 0, which is unlimited
+	for _, size := range []struct{ width, height, wantWidth, wantHeight int }{
+		{0, 0, 0, 1},
+		{1, 1, 1, 1},
+		{4, 2, 4, 2},
+		{-5, -5, 1, 1},
+	} {
+		v := NewJSONViewer(generatedDocument(3))
+		v.SetViewportHeight(size.height)
+		v.SetViewportWidth(size.width)
+		v.MoveToBottom()
+		v.ScrollRight(10)
+		assertRenderFits(t, v.Render(), size.wantWidth, size.wantHeight)
+	}
+}
+
+func TestFindByPath(t *testing.T) {
+	v := NewJSONViewer(generatedDocument(3))
+	v.CollapseAll()
+
+	line, err := v.FindByPath(".[2].address.city")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node := v.CurrentNode(); line != v.CursorPosition()-1 || node.Path != ".[2].address.city" {
+		t.Fatalf("went to line %d, %s", line, node.DisplayPath())
+	}
+
+	// Errors say where the path left the tree
+	for path, want := range map[string]string{
+		".[9]":         "index 9 out of range",
+		".[0].missing": `key "missing" not found at .[0]`,
+		".[0].id.x":    "cannot look up key",
+	} {
+		if _, err := v.FindByPath(path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got error %v, want one containing %q", path, err, want)
+		}
+	}
+}
+
+func BenchmarkMoveDownRender(b *testing.B) {
+	v := NewJSONViewer(generatedDocument(benchmarkElements))
+	v.SetViewportHeight(40)
+	b.ResetTimer()
+	for range b.N {
+		if v.cursor == len(v.visibleNodes)-1 {
+			v.MoveToTop()
+		}
+		v.MoveDown()
+		v.Render()
+	}
+}
+
+func BenchmarkToggleNode(b *testing.B) {
+	v := NewJSONViewer(generatedDocument(benchmarkElements))
+	v.SetViewportHeight(40)
+
+	// An element in the middle, so both ends of the list are spliced around it
+	for i, node := range v.visibleNodes {
+		if node.Path == fmt.Sprintf(".[%d]", benchmarkElements/2) {
+			v.cursor = i
+			break
+		}
+	}
+	b.ResetTimer()
+	for range b.N {
+		v.ToggleNode()
+	}
+}
+
+// BenchmarkMoveDownRenderLargeArray moves through a 100k-element array near
+// its start and near its end, which should cost the same
+func BenchmarkMoveDownRenderLargeArray(b *testing.B) {
+	elements := make([]interface{}, 100000)
+	for i := range elements {
+		elements[i] = float64(i)
+	}
+	v := NewJSONViewer(model.NewJSONNode("root", elements, nil))
+	v.SetViewportHeight(40)
+
+	for _, bench := range []struct {
+		name  string
+		start int
+	}{
+		{"start", 0},
+		{"end", len(elements) - 1000},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			v.cursor = bench.start
+			v.ensureCursorVisible()
+			for i := range b.N {
+				if i%900 == 0 {
+					v.cursor = bench.start
+				}
+				v.MoveDown()
+				v.Render()
+			}
+		})
+	}
+}
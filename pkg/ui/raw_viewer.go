@@ -0,0 +1,200 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"tablux/pkg/model"
+)
+
+// rawIndent is the indentation of each nesting level of the raw view
+const rawIndent = "  "
+
+// RawViewer shows a tree as pretty-printed JSON text, highlighted with the
+// JSON node styles. It has no cursor; moving scrolls the text.
+type RawViewer struct {
+	lines          []string
+	viewportY      int
+	viewportHeight int
+	viewportWidth  int // Lines are clipped to this many cells, or not at all when 0
+	xOffset        int // Cells scrolled off the left edge
+}
+
+// NewRawViewer creates a viewer of the JSON text of root and everything below
+// it, with object keys in their original order
+func NewRawViewer(root *model.JSONNode) *RawViewer {
+	var sb strings.Builder
+	writeRawValue(&sb, root.RawValue(), "")
+	return &RawViewer{
+		lines:          strings.Split(sb.String(), "\n"),
+		viewportHeight: 20, // Default height
+	}
+}
+
+// writeRawValue writes a value as highlighted JSON, its nested lines
+// indented one level deeper than indent
+func writeRawValue(sb *strings.Builder, value interface{}, indent string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		// Plain maps carry no key order, so sort keys as the tree does
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		entries := make(model.OrderedObject, 0, len(keys))
+		for _, key := range keys {
+			entries = append(entries, model.ObjectEntry{Key: key, Value: v[key]})
+		}
+		writeRawValue(sb, entries, indent)
+	case model.OrderedObject:
+		if len(v) == 0 {
+			sb.WriteString(bracketStyle.Render("{}"))
+			return
+		}
+		sb.WriteString(bracketStyle.Render("{"))
+		for i, entry := range v {
+			sb.WriteString("\n" + indent + rawIndent)
+			sb.WriteString(keyStyle.Render(rawScalar(entry.Key)))
+			sb.WriteString(jsonSeparatorStyle.Render(": "))
+			writeRawValue(sb, entry.Value, indent+rawIndent)
+			if i < len(v)-1 {
+				sb.WriteString(jsonSeparatorStyle.Render(","))
+			}
+		}
+		sb.WriteString("\n" + indent + bracketStyle.Render("}"))
+	case []interface{}:
+		if len(v) == 0 {
+			sb.WriteString(bracketStyle.Render("[]"))
+			return
+		}
+		sb.WriteString(bracketStyle.Render("["))
+		for i, element := range v {
+			sb.WriteString("\n" + indent + rawIndent)
+			writeRawValue(sb, element, indent+rawIndent)
+			if i < len(v)-1 {
+				sb.WriteString(jsonSeparatorStyle.Render(","))
+			}
+		}
+		sb.WriteString("\n" + indent + bracketStyle.Render("]"))
+	case string:
+		sb.WriteString(stringStyle.Render(rawScalar(v)))
+	case bool:
+		sb.WriteString(boolStyle.Render(rawScalar(v)))
+	case nil:
+		sb.WriteString(nullStyle.Render("null"))
+	default:
+		sb.WriteString(numberStyle.Render(rawScalar(v)))
+	}
+}
+
+// rawScalar encodes a scalar as JSON without escaping HTML characters, so
+// numbers keep the digits they were written with
+func rawScalar(value interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return model.String(value)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// SetViewportHeight sets the height of the viewport, of at least one line
+func (v *RawViewer) SetViewportHeight(height int) {
+	v.viewportHeight = clampViewportHeight(height)
+	v.ScrollUp(0)
+}
+
+// SetViewportWidth sets how many cells wide rendered lines may be. Longer
+// lines are cut off with "...", and 0 leaves them whole.
+func (v *RawViewer) SetViewportWidth(width int) {
+	v.viewportWidth = clampViewportWidth(width)
+}
+
+// ScrollUp moves the text down n lines, stopping at the first line
+func (v *RawViewer) ScrollUp(n int) {
+	v.viewportY = max(min(v.viewportY-n, v.maxY()), 0)
+}
+
+// ScrollDown moves the text up n lines, stopping once the last line reaches
+// the bottom of the viewport
+func (v *RawViewer) ScrollDown(n int) {
+	v.viewportY = max(min(v.viewportY+n, v.maxY()), 0)
+}
+
+// ScrollToTop shows the first line
+func (v *RawViewer) ScrollToTop() {
+	v.viewportY = 0
+}
+
+// ScrollToBottom shows the last line at the bottom of the viewport
+func (v *RawViewer) ScrollToBottom() {
+	v.viewportY = v.maxY()
+}
+
+// maxY returns the furthest the viewport can scroll down
+func (v *RawViewer) maxY() int {
+	return max(len(v.lines)-v.viewportHeight, 0)
+}
+
+// ScrollLeft moves the view n cells to the left
+func (v *RawViewer) ScrollLeft(n int) {
+	v.xOffset = max(v.xOffset-n, 0)
+}
+
+// ScrollRight moves the view n cells to the right, and stops once the end
+// of the widest line on screen is in view
+func (v *RawViewer) ScrollRight(n int) {
+	if v.viewportWidth <= 0 {
+		return
+	}
+
+	widest := 0
+	for _, line := range v.visibleLines() {
+		widest = max(widest, ansi.StringWidth(line))
+	}
+	maxOffset := max(widest-v.viewportWidth, 0)
+	v.xOffset = max(min(v.xOffset+n, maxOffset), min(v.xOffset, maxOffset))
+}
+
+// visibleLines returns the lines in the viewport
+func (v *RawViewer) visibleLines() []string {
+	start := min(max(v.viewportY, 0), len(v.lines))
+	return v.lines[start:min(start+v.viewportHeight, len(v.lines))]
+}
+
+// FirstLine returns the 1-based number of the top line on screen
+func (v *RawViewer) FirstLine() int {
+	return v.viewportY + 1
+}
+
+// LineCount returns the number of lines of text
+func (v *RawViewer) LineCount() int {
+	return len(v.lines)
+}
+
+// ScrollPercent returns how far the viewport has scrolled through the
+// text, from 0 to 100, and whether all of it fits on screen
+func (v *RawViewer) ScrollPercent() (int, bool) {
+	return scrollPercent(v.viewportY, len(v.lines), v.viewportHeight)
+}
+
+// Render renders the lines in the viewport
+func (v *RawViewer) Render() string {
+	var sb strings.Builder
+	for _, line := range v.visibleLines() {
+		if v.viewportWidth > 0 {
+			if v.xOffset > 0 {
+				line = ansi.TruncateLeft(line, v.xOffset, "")
+			}
+			line = truncate(line, v.viewportWidth)
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,20 @@
+package ui
+
+import "testing"
+
+func TestRawRenderTinyViewports(t *testing.T) {
+	// Sizes below one are raised to one, except a width of 0, which is unlimited
+	for _, size := range []struct{ width, height, wantWidth, wantHeight int }{
+		{0, 0, 0, 1},
+		{1, 1, 1, 1},
+		{4, 2, 4, 2},
+		{-5, -5, 1, 1},
+	} {
+		v := NewRawViewer(generatedDocument(3))
+		v.SetViewportHeight(size.height)
+		v.SetViewportWidth(size.width)
+		v.ScrollToBottom()
+		v.ScrollRight(10)
+		assertRenderFits(t, v.Render(), size.wantWidth, size.wantHeight)
+	}
+}
@@ -1,40 +1,330 @@
 package ui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme holds the colors and symbols the viewers are drawn with. Colors are
+// hex strings; an empty color leaves the terminal's default.
+type Theme struct {
+	Name string `json:"name" toml:"name"`
 
-// Common theme colors
-const (
 	// Base colors
-	PrimaryColor    = "#4B6BEF"
-	SecondaryColor  = "#5A5AA0"
-	HighlightColor  = "#5555CC"
-	TextColor       = "#FFFFFF"
-	MutedTextColor  = "#AAAAAA"
-	BackgroundColor = "#333333"
+	Primary         string `json:"primary" toml:"primary"`
+	Secondary       string `json:"secondary" toml:"secondary"`
+	Highlight       string `json:"highlight" toml:"highlight"`
+	Text            string `json:"text" toml:"text"`
+	MutedText       string `json:"muted_text" toml:"muted_text"`
+	Background      string `json:"background" toml:"background"`
+	CollapsedHeader string `json:"collapsed_header" toml:"collapsed_header"`
+	Stripe          string `json:"stripe" toml:"stripe"` // Background of alternate table rows when Zebra is set
+
+	// Application chrome colors; Footer is drawn on the terminal's own background
+	Title  string `json:"title" toml:"title"`
+	Footer string `json:"footer" toml:"footer"`
+	Error  string `json:"error" toml:"error"`
 
 	// JSON node colors
-	KeyColor     = "#88AAFF"
-	StringColor  = "#7CFC00"
-	NumberColor  = "#FFD700"
-	BoolColor    = "#FF9F5F"
-	NullColor    = "#FF5F5F"
-	BracketColor = "#F8F8F2"
+	Key     string `json:"key" toml:"key"`
+	String  string `json:"string" toml:"string"`
+	Number  string `json:"number" toml:"number"`
+	Bool    string `json:"bool" toml:"bool"`
+	Null    string `json:"null" toml:"null"`
+	Bracket string `json:"bracket" toml:"bracket"`
+
+	// Lint colors
+	Warning string `json:"warning" toml:"warning"`
+
+	// Diff colors
+	Added   string `json:"added" toml:"added"`
+	Removed string `json:"removed" toml:"removed"`
+	Changed string `json:"changed" toml:"changed"`
+
+	// Search colors
+	SearchMatch     string `json:"search_match" toml:"search_match"`
+	SearchMatchText string `json:"search_match_text" toml:"search_match_text"`
 
 	// UI symbols
-	ExpandedIndicator  = "▼ "
-	CollapsedIndicator = "► "
-	CollapsedColumn    = "│"
-	SortAscIndicator   = " ▲"
-	SortDescIndicator  = " ▼"
-)
+	Expanded          string `json:"expanded" toml:"expanded"`
+	Collapsed         string `json:"collapsed" toml:"collapsed"`
+	CollapsedColumn   string `json:"collapsed_column" toml:"collapsed_column"`
+	SortAsc           string `json:"sort_asc" toml:"sort_asc"`
+	SortDesc          string `json:"sort_desc" toml:"sort_desc"`
+	NumericStringMark string `json:"numeric_string_mark" toml:"numeric_string_mark"`
+	TreePipe          string `json:"tree_pipe" toml:"tree_pipe"`
+	TreeTee           string `json:"tree_tee" toml:"tree_tee"`
+	TreeLast          string `json:"tree_last" toml:"tree_last"`
+	TrueSymbol        string `json:"true_symbol" toml:"true_symbol"` // Shown for tree booleans and nulls when ValueSymbols is set
+	FalseSymbol       string `json:"false_symbol" toml:"false_symbol"`
+	NullSymbol        string `json:"null_symbol" toml:"null_symbol"`
+
+	// ASCII replaces the symbols and table borders with plain ASCII, for
+	// terminals or fonts without box-drawing and arrow glyphs
+	ASCII bool `json:"ascii" toml:"ascii"`
+
+	// Zebra gives every other table row the Stripe background
+	Zebra bool `json:"zebra" toml:"zebra"`
+
+	// ValueSymbols shows tree booleans and nulls as TrueSymbol, FalseSymbol
+	// and NullSymbol instead of the words, for more compact trees
+	ValueSymbols bool `json:"value_symbols" toml:"value_symbols"`
+}
+
+// DarkTheme is the default theme, for dark terminal backgrounds
+var DarkTheme = Theme{
+	Name: "dark",
+
+	Primary:         "#4B6BEF",
+	Secondary:       "#5A5AA0",
+	Highlight:       "#5555CC",
+	Text:            "#FFFFFF",
+	MutedText:       "#AAAAAA",
+	Background:      "#333333",
+	CollapsedHeader: "#777777",
+	Stripe:          "#262626",
+
+	Title:  "#7D56F4",
+	Footer: "#FAFAFA",
+	Error:  "#FF5555",
+
+	Key:     "#88AAFF",
+	String:  "#7CFC00",
+	Number:  "#FFD700",
+	Bool:    "#FF9F5F",
+	Null:    "#FF5F5F",
+	Bracket: "#F8F8F2",
+
+	Warning: "#FF79C6",
+
+	Added:   "#50FA7B",
+	Removed: "#FF5555",
+	Changed: "#F1FA8C",
+
+	SearchMatch:     "#FFB86C",
+	SearchMatchText: "#000000",
+
+	Expanded:          "▼ ",
+	Collapsed:         "► ",
+	CollapsedColumn:   "│",
+	SortAsc:           " ▲",
+	SortDesc:          " ▼",
+	NumericStringMark: " ≠#",
+	TreePipe:          "│ ",
+	TreeTee:           "├─",
+	TreeLast:          "└─",
+	TrueSymbol:        "✓",
+	FalseSymbol:       "✗",
+	NullSymbol:        "∅",
+}
+
+// LightTheme is tuned for light terminal backgrounds
+var LightTheme = withColors(DarkTheme, Theme{
+	Name: "light",
+
+	Primary:         "#3B5BDB",
+	Secondary:       "#5C6BC0",
+	Highlight:       "#3949AB",
+	Text:            "#FFFFFF",
+	MutedText:       "#6B6B6B",
+	Background:      "#E4E4E4",
+	CollapsedHeader: "#9E9E9E",
+	Stripe:          "#F2F2F2",
+
+	Title:  "#5B3CC4",
+	Footer: "#333333",
+	Error:  "#C62828",
+
+	Key:     "#1A4FB3",
+	String:  "#2E7D32",
+	Number:  "#B35C00",
+	Bool:    "#AD1457",
+	Null:    "#C62828",
+	Bracket: "#333333",
+
+	Warning: "#C2185B",
+
+	Added:   "#2E7D32",
+	Removed: "#C62828",
+	Changed: "#B35C00",
+
+	SearchMatch:     "#FFD54F",
+	SearchMatchText: "#000000",
+})
+
+// SolarizedTheme uses the Solarized dark palette
+var SolarizedTheme = withColors(DarkTheme, Theme{
+	Name: "solarized",
+
+	Primary:         "#268BD2",
+	Secondary:       "#6C71C4",
+	Highlight:       "#2AA198",
+	Text:            "#FDF6E3",
+	MutedText:       "#93A1A1",
+	Background:      "#073642",
+	CollapsedHeader: "#586E75",
+	Stripe:          "#04303B",
+
+	Title:  "#6C71C4",
+	Footer: "#93A1A1",
+	Error:  "#DC322F",
+
+	Key:     "#268BD2",
+	String:  "#859900",
+	Number:  "#B58900",
+	Bool:    "#CB4B16",
+	Null:    "#DC322F",
+	Bracket: "#93A1A1",
+
+	Warning: "#D33682",
+
+	Added:   "#859900",
+	Removed: "#DC322F",
+	Changed: "#B58900",
+
+	SearchMatch:     "#B58900",
+	SearchMatchText: "#002B36",
+})
+
+// builtinThemes are the themes selectable by name
+var builtinThemes = map[string]Theme{
+	DarkTheme.Name:      DarkTheme,
+	LightTheme.Name:     LightTheme,
+	SolarizedTheme.Name: SolarizedTheme,
+}
+
+// withColors returns base with the colors and name of colors applied,
+// keeping base's symbols
+func withColors(base, colors Theme) Theme {
+	colors.Expanded = base.Expanded
+	colors.Collapsed = base.Collapsed
+	colors.CollapsedColumn = base.CollapsedColumn
+	colors.SortAsc = base.SortAsc
+	colors.SortDesc = base.SortDesc
+	colors.NumericStringMark = base.NumericStringMark
+	colors.TreePipe = base.TreePipe
+	colors.TreeTee = base.TreeTee
+	colors.TreeLast = base.TreeLast
+	colors.TrueSymbol = base.TrueSymbol
+	colors.FalseSymbol = base.FalseSymbol
+	colors.NullSymbol = base.NullSymbol
+	colors.ASCII = base.ASCII
+	colors.Zebra = base.Zebra
+	colors.ValueSymbols = base.ValueSymbols
+	return colors
+}
+
+// withASCIISymbols returns theme with every symbol replaced by an ASCII equivalent
+func withASCIISymbols(theme Theme) Theme {
+	theme.Expanded = "- "
+	theme.Collapsed = "+ "
+	theme.CollapsedColumn = "|"
+	theme.SortAsc = " ^"
+	theme.SortDesc = " v"
+	theme.NumericStringMark = " !#"
+	theme.TreePipe = "| "
+	theme.TreeTee = "+-"
+	theme.TreeLast = "`-"
+	theme.TrueSymbol = "T"
+	theme.FalseSymbol = "F"
+	theme.NullSymbol = "~"
+	return theme
+}
+
+// BuiltinTheme returns the built-in theme with the given name
+func BuiltinTheme(name string) (Theme, bool) {
+	theme, ok := builtinThemes[strings.ToLower(name)]
+	return theme, ok
+}
+
+// AutoThemeName selects the dark or light theme to match the terminal background
+const AutoThemeName = "auto"
+
+// DetectTheme returns LightTheme when the terminal reports a light
+// background and DarkTheme otherwise, including when it can't tell
+func DetectTheme() Theme {
+	if lipgloss.HasDarkBackground() {
+		return DarkTheme
+	}
+	return LightTheme
+}
+
+// ThemeNames returns the names of the built-in themes in alphabetical order
+func ThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadTheme reads a theme from a JSON or TOML file. Values the file leaves
+// out are taken from DarkTheme, so a theme only needs the colors it changes.
+func LoadTheme(r io.Reader) (Theme, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Theme{}, fmt.Errorf("failed to read theme: %w", err)
+	}
+
+	theme := DarkTheme
+	theme.Name = ""
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(data, &theme); err != nil {
+			return Theme{}, fmt.Errorf("failed to parse JSON theme: %w", err)
+		}
+	} else if err := toml.Unmarshal(data, &theme); err != nil {
+		return Theme{}, fmt.Errorf("failed to parse TOML theme: %w", err)
+	}
+
+	return theme, nil
+}
+
+// activeTheme is the theme the styles are currently built from
+var activeTheme = DarkTheme
+
+// SetTheme makes theme the active theme and rebuilds every style from it
+func SetTheme(theme Theme) {
+	if theme.ASCII {
+		theme = withASCIISymbols(theme)
+	}
+	activeTheme = theme
+	buildStyles()
+}
+
+// ActiveTheme returns the theme the styles are currently built from
+func ActiveTheme() Theme {
+	return activeTheme
+}
 
 // Default dimensions and spacing
 const (
 	DefaultCellPadding    = 1
 	DefaultColumnMaxWidth = 30
+	MinColumnMaxWidth     = 10 // Narrowest a column may be capped to
+	ColumnMaxWidthStep    = 10 // Change applied by each widen/narrow key press
+	MinFitColumnWidth     = 6  // Narrowest auto-fit squeezes a column to, padding included
 	CollapsedColumnWidth  = 2
 )
 
+// colorsEnabled controls whether styles carry foreground/background colors
+var colorsEnabled = true
+
+// themeColor returns the color for a hex value, or no color when colors are disabled
+func themeColor(hex string) lipgloss.TerminalColor {
+	if !colorsEnabled || hex == "" {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(hex)
+}
+
 // CreateStyle creates a standard cell style with common settings
 func CreateStyle(fg, bg string, bold bool) lipgloss.Style {
 	style := lipgloss.NewStyle().
@@ -43,11 +333,11 @@ func CreateStyle(fg, bg string, bold bool) lipgloss.Style {
 		AlignHorizontal(lipgloss.Left)
 
 	if fg != "" {
-		style = style.Foreground(lipgloss.Color(fg))
+		style = style.Foreground(themeColor(fg))
 	}
 
 	if bg != "" {
-		style = style.Background(lipgloss.Color(bg))
+		style = style.Background(themeColor(bg))
 	}
 
 	if bold {
@@ -60,35 +350,113 @@ func CreateStyle(fg, bg string, bold bool) lipgloss.Style {
 // Common styles that can be shared across viewers
 var (
 	// Basic styles
-	HeaderStyle = CreateStyle(TextColor, PrimaryColor, true)
-	CellStyle   = CreateStyle("", "", false)
+	HeaderStyle      lipgloss.Style
+	CellStyle        lipgloss.Style
+	StripedCellStyle lipgloss.Style
 
 	// Selection styles
-	SelectedRowStyle  = CreateStyle("", BackgroundColor, false)
-	SelectedColStyle  = CreateStyle(TextColor, SecondaryColor, false)
-	SelectedCellStyle = CreateStyle(TextColor, HighlightColor, true)
+	SelectedRowStyle  lipgloss.Style
+	SelectedColStyle  lipgloss.Style
+	SelectedCellStyle lipgloss.Style
 
 	// Collapsed styles
-	CollapsedHeaderStyle = CreateStyle(TextColor, "#777777", true).Width(CollapsedColumnWidth)
-	CollapsedCellStyle   = CreateStyle(MutedTextColor, BackgroundColor, false).Width(CollapsedColumnWidth)
+	CollapsedHeaderStyle lipgloss.Style
+	CollapsedCellStyle   lipgloss.Style
+
+	// Row number gutter style
+	RowNumberStyle lipgloss.Style
 
 	// JSON specific styles
-	KeyStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color(KeyColor))
-	StringStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color(StringColor))
-	NumberStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color(NumberColor))
-	BoolStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color(BoolColor))
-	NullStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color(NullColor))
-	BracketStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color(BracketColor))
-	SelectedNodeStyle = lipgloss.NewStyle().Background(lipgloss.Color(BackgroundColor))
-	SeparatorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color(MutedTextColor))
+	KeyStyle            lipgloss.Style
+	IndexStyle          lipgloss.Style
+	StringStyle         lipgloss.Style
+	NumberStyle         lipgloss.Style
+	BoolStyle           lipgloss.Style
+	NullStyle           lipgloss.Style
+	BracketStyle        lipgloss.Style
+	SelectedNodeStyle   lipgloss.Style
+	SeparatorStyle      lipgloss.Style
+	TypeAnnotationStyle lipgloss.Style
+	NumericStringStyle  lipgloss.Style
+	DuplicateKeyStyle   lipgloss.Style
+
+	// Diff styles; without colors they differ by emphasis instead
+	DiffAddedStyle   lipgloss.Style
+	DiffRemovedStyle lipgloss.Style
+	DiffChangedStyle lipgloss.Style
+
+	// Search styles
+	SearchMatchStyle lipgloss.Style
 )
 
-// Tree symbols for JSON viewer
-var TreeSymbols = map[string]string{
-	"pipe":      "│ ",
-	"tee":       "├─",
-	"last":      "└─",
-	"expanded":  ExpandedIndicator,
-	"collapsed": CollapsedIndicator,
-	"empty":     "  ",
+func init() {
+	buildStyles()
+}
+
+// DisableColors rebuilds every style without foreground or background colors.
+// The cursor falls back to reverse video so it stays visible.
+func DisableColors() {
+	colorsEnabled = false
+	buildStyles()
 }
+
+// ColorsEnabled reports whether styles are rendered with colors
+func ColorsEnabled() bool {
+	return colorsEnabled
+}
+
+// buildStyles (re)creates the shared styles from the theme colors and
+// refreshes the per-viewer style aliases
+func buildStyles() {
+	t := activeTheme
+
+	HeaderStyle = CreateStyle(t.Text, t.Primary, true)
+	CellStyle = CreateStyle("", "", false)
+	StripedCellStyle = CreateStyle("", t.Stripe, false)
+
+	SelectedRowStyle = CreateStyle("", t.Background, false)
+	SelectedColStyle = CreateStyle(t.Text, t.Secondary, false)
+	SelectedCellStyle = CreateStyle(t.Text, t.Highlight, true).Reverse(!colorsEnabled)
+
+	CollapsedHeaderStyle = CreateStyle(t.Text, t.CollapsedHeader, true).Width(CollapsedColumnWidth)
+	CollapsedCellStyle = CreateStyle(t.MutedText, t.Background, false).Width(CollapsedColumnWidth)
+
+	RowNumberStyle = CreateStyle(t.MutedText, "", false).Faint(true).AlignHorizontal(lipgloss.Right)
+
+	KeyStyle = lipgloss.NewStyle().Foreground(themeColor(t.Key))
+	IndexStyle = lipgloss.NewStyle().Foreground(themeColor(t.Key)).Faint(true)
+	StringStyle = lipgloss.NewStyle().Foreground(themeColor(t.String))
+	NumberStyle = lipgloss.NewStyle().Foreground(themeColor(t.Number))
+	BoolStyle = lipgloss.NewStyle().Foreground(themeColor(t.Bool))
+	NullStyle = lipgloss.NewStyle().Foreground(themeColor(t.Null))
+	BracketStyle = lipgloss.NewStyle().Foreground(themeColor(t.Bracket))
+	SelectedNodeStyle = lipgloss.NewStyle().Background(themeColor(t.Background)).Reverse(!colorsEnabled)
+	SeparatorStyle = lipgloss.NewStyle().Foreground(themeColor(t.MutedText))
+	NumericStringStyle = lipgloss.NewStyle().Foreground(themeColor(t.Warning)).Bold(!colorsEnabled)
+	TypeAnnotationStyle = lipgloss.NewStyle().Foreground(themeColor(t.MutedText)).Faint(true).Italic(true)
+	DuplicateKeyStyle = lipgloss.NewStyle().Foreground(themeColor(t.Warning)).Underline(true)
+
+	DiffAddedStyle = lipgloss.NewStyle().Foreground(themeColor(t.Added)).Bold(!colorsEnabled)
+	DiffRemovedStyle = lipgloss.NewStyle().Foreground(themeColor(t.Removed)).Strikethrough(!colorsEnabled)
+	DiffChangedStyle = lipgloss.NewStyle().Foreground(themeColor(t.Changed)).Underline(!colorsEnabled)
+
+	SearchMatchStyle = lipgloss.NewStyle().
+		Foreground(themeColor(t.SearchMatchText)).
+		Background(themeColor(t.SearchMatch)).
+		Underline(!colorsEnabled)
+
+	TreeSymbols = map[string]string{
+		"pipe":      t.TreePipe,
+		"tee":       t.TreeTee,
+		"last":      t.TreeLast,
+		"expanded":  t.Expanded,
+		"collapsed": t.Collapsed,
+		"empty":     "  ",
+	}
+
+	applyCSVStyles()
+	applyJSONStyles()
+}
+
+// Tree symbols for JSON viewer, built from the theme by buildStyles
+var TreeSymbols map[string]string
@@ -0,0 +1,262 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"tablux/pkg/model"
+	"tablux/pkg/parser"
+)
+
+// Output format string representations
+const (
+	FormatJSON     = "json"
+	FormatJSONL    = "jsonl"
+	FormatCSV      = "csv"
+	FormatTSV      = "tsv"
+	FormatMarkdown = "markdown"
+)
+
+// DefaultIndent is the indentation used for pretty-printed JSON
+const DefaultIndent = "  "
+
+// WriteJSON writes a value as indented JSON followed by a newline
+func WriteJSON(w io.Writer, value interface{}) error {
+	return WriteJSONIndent(w, value, DefaultIndent)
+}
+
+// WriteJSONIndent writes a value as JSON followed by a newline, indenting
+// nested levels by indent. An empty indent writes compact JSON.
+func WriteJSONIndent(w io.Writer, value interface{}, indent string) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(value); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
+
+// WriteJSONL writes each value as compact JSON on its own line
+func WriteJSONL(w io.Writer, values []interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for i, value := range values {
+		if err := enc.Encode(value); err != nil {
+			return fmt.Errorf("failed to write JSONL record %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes headers and rows using encoding/csv, so fields are quoted as needed
+func WriteCSV(w io.Writer, data *parser.CSVData) error {
+	return writeDelimited(w, data, ',')
+}
+
+// WriteTSV writes headers and rows as tab-separated values
+func WriteTSV(w io.Writer, data *parser.CSVData) error {
+	return writeDelimited(w, data, '\t')
+}
+
+// writeDelimited writes headers and rows separated by the given delimiter
+func writeDelimited(w io.Writer, data *parser.CSVData, delimiter rune) error {
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Comma = delimiter
+	if err := csvWriter.Write(data.Headers); err != nil {
+		return fmt.Errorf("failed to write CSV headers: %w", err)
+	}
+	if err := csvWriter.WriteAll(data.Rows); err != nil {
+		return fmt.Errorf("failed to write CSV rows: %w", err)
+	}
+	return nil
+}
+
+// WriteMarkdown writes a GitHub-flavored Markdown table. Hidden columns are
+// omitted and rows are written in their current (possibly sorted) order.
+func WriteMarkdown(w io.Writer, data *parser.CSVData) error {
+	columns := data.GetVisibleColumns()
+	if len(columns) == 0 {
+		return fmt.Errorf("no visible columns to write")
+	}
+
+	var buf bytes.Buffer
+
+	// Header and separator rows
+	writeMarkdownRow(&buf, columns, data.Headers)
+	buf.WriteString("|")
+	for _, col := range columns {
+		if data.ColumnTypeOf(col).IsNumeric() {
+			buf.WriteString(" ---: |")
+		} else {
+			buf.WriteString(" --- |")
+		}
+	}
+	buf.WriteString("\n")
+
+	// Data rows
+	for _, row := range data.Rows {
+		writeMarkdownRow(&buf, columns, row)
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write Markdown: %w", err)
+	}
+	return nil
+}
+
+// writeMarkdownRow writes the given columns of a row as a table line
+func writeMarkdownRow(buf *bytes.Buffer, columns []int, cells []string) {
+	buf.WriteString("|")
+	for _, col := range columns {
+		cell := ""
+		if col < len(cells) {
+			cell = cells[col]
+		}
+		buf.WriteString(" ")
+		buf.WriteString(escapeMarkdownCell(cell))
+		buf.WriteString(" |")
+	}
+	buf.WriteString("\n")
+}
+
+// escapeMarkdownCell escapes pipes and replaces newlines so a cell stays on one line
+func escapeMarkdownCell(cell string) string {
+	cell = strings.ReplaceAll(cell, "\\", "\\\\")
+	cell = strings.ReplaceAll(cell, "|", "\\|")
+	cell = strings.ReplaceAll(cell, "\r\n", "<br>")
+	cell = strings.ReplaceAll(cell, "\n", "<br>")
+	return cell
+}
+
+// CSVToValues converts CSV rows into objects keyed by header, in column order
+func CSVToValues(data *parser.CSVData) []interface{} {
+	values := make([]interface{}, 0, len(data.Rows))
+	for _, row := range data.Rows {
+		obj := make(model.OrderedObject, 0, len(data.Headers))
+		for i, header := range data.Headers {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			obj = append(obj, model.ObjectEntry{Key: header, Value: cell})
+		}
+		values = append(values, obj)
+	}
+	return values
+}
+
+// NodeValues returns the records held by a JSON tree: the elements of a root
+// array, or the root value itself otherwise
+func NodeValues(root *model.JSONNode) []interface{} {
+	if arr, ok := root.Value.([]interface{}); ok {
+		return arr
+	}
+	return []interface{}{root.Value}
+}
+
+// NodeToCSVData converts a JSON array of objects into CSV data. Headers are
+// the object keys in first-seen order; nested values are written as compact JSON.
+func NodeToCSVData(root *model.JSONNode) (*parser.CSVData, error) {
+	return nodeToCSVData(root, false)
+}
+
+// NodeToFlatCSVData converts a JSON array of objects into CSV data like
+// NodeToCSVData, but flattens nested objects into dot-separated columns,
+// e.g. {"user": {"name": "Ann"}} becomes a user.name column
+func NodeToFlatCSVData(root *model.JSONNode) (*parser.CSVData, error) {
+	return nodeToCSVData(root, true)
+}
+
+// nodeToCSVData converts a JSON array of objects into CSV data, flattening
+// nested objects into dotted columns when flatten is set
+func nodeToCSVData(root *model.JSONNode, flatten bool) (*parser.CSVData, error) {
+	if root.Type != model.NodeArray {
+		return nil, fmt.Errorf("CSV output requires a JSON array of objects, got %s", root.TypeString())
+	}
+
+	data := parser.NewCSVData()
+	columns := make(map[string]int)
+
+	// First pass: collect headers from every object
+	root.LoadChildren()
+	records := make([][]csvField, 0, len(root.Children))
+	for i, element := range root.Children {
+		if element.Type != model.NodeObject {
+			return nil, fmt.Errorf("CSV output requires a JSON array of objects, element %d is %s", i, element.TypeString())
+		}
+		fields := collectFields(element, "", flatten)
+		for _, field := range fields {
+			if _, seen := columns[field.key]; !seen {
+				columns[field.key] = len(data.Headers)
+				data.Headers = append(data.Headers, field.key)
+			}
+		}
+		records = append(records, fields)
+	}
+
+	// Second pass: fill rows, leaving absent keys empty
+	for _, fields := range records {
+		row := make([]string, len(data.Headers))
+		for _, field := range fields {
+			cell, err := cellValue(field.node)
+			if err != nil {
+				return nil, err
+			}
+			row[columns[field.key]] = cell
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	data.ColumnVisibility = make([]bool, len(data.Headers))
+	for i := range data.ColumnVisibility {
+		data.ColumnVisibility[i] = true
+	}
+
+	return data, nil
+}
+
+// csvField is an object field destined for the column named key
+type csvField struct {
+	key  string
+	node *model.JSONNode
+}
+
+// collectFields lists the fields of an object in order, prefixing keys with
+// prefix. With flatten set, non-empty nested objects are expanded into their
+// own fields instead of being kept as a single JSON cell.
+func collectFields(object *model.JSONNode, prefix string, flatten bool) []csvField {
+	var fields []csvField
+	object.LoadChildren()
+	for _, child := range object.Children {
+		key := prefix + child.Key
+		if flatten && child.Type == model.NodeObject && child.HasChildren() {
+			fields = append(fields, collectFields(child, key+".", flatten)...)
+			continue
+		}
+		fields = append(fields, csvField{key: key, node: child})
+	}
+	return fields
+}
+
+// cellValue converts a node into a CSV cell
+func cellValue(node *model.JSONNode) (string, error) {
+	switch node.Type {
+	case model.NodeObject, model.NodeArray:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(node.Value); err != nil {
+			return "", fmt.Errorf("failed to encode %s: %w", node.Key, err)
+		}
+		return string(bytes.TrimRight(buf.Bytes(), "\n")), nil
+	case model.NodeNull:
+		return "", nil
+	default:
+		return model.InterfaceToString(node.Value), nil
+	}
+}
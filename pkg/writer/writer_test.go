@@ -0,0 +1,55 @@
+package writer
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"slices"
+	"testing"
+
+	"tablux/pkg/parser"
+)
+
+// TestDelimitedRoundTrip parses a file with quoting, embedded delimiters,
+// quotes, newlines, tabs and edge whitespace, writes it out and parses the
+// output again, which must give back the same table
+func TestDelimitedRoundTrip(t *testing.T) {
+	data, err := os.ReadFile("../../test/quoted.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := parser.NewCSVParser().Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []struct {
+		name      string
+		write     func(io.Writer, *parser.CSVData) error
+		delimiter rune
+	}{
+		{"csv", WriteCSV, ','},
+		{"tsv", WriteTSV, '\t'},
+	} {
+		t.Run(format.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := format.write(&buf, original); err != nil {
+				t.Fatal(err)
+			}
+
+			csvParser := parser.NewCSVParser()
+			csvParser.Comma = format.delimiter
+			reparsed, err := csvParser.Parse(buf.Bytes())
+			if err != nil {
+				t.Fatalf("failed to parse the written %s: %v\n%s", format.name, err, buf.String())
+			}
+
+			if !slices.Equal(reparsed.Headers, original.Headers) {
+				t.Errorf("got headers %q, want %q", reparsed.Headers, original.Headers)
+			}
+			if !slices.EqualFunc(reparsed.Rows, original.Rows, slices.Equal[[]string]) {
+				t.Errorf("got rows %q, want %q", reparsed.Rows, original.Rows)
+			}
+		})
+	}
+}